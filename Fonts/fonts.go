@@ -0,0 +1,35 @@
+// Package fonts bundles the TrueType fonts in this directory into the binary.
+package fonts
+
+import (
+	"embed"
+	"io/fs"
+	"strings"
+)
+
+//go:embed *.ttf
+var files embed.FS
+
+// Names of the bundled fonts, without the .ttf extension (ex: "MC")
+func Names() []string {
+	entries, _ := fs.ReadDir(files, ".")
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".ttf"))
+	}
+
+	return names
+}
+
+// Returns the raw bytes of the bundled font called name, with or without the .ttf extension
+func Bytes(name string) ([]byte, bool) {
+	name = strings.TrimSuffix(name, ".ttf")
+
+	b, err := files.ReadFile(name + ".ttf")
+	if err != nil {
+		return nil, false
+	}
+
+	return b, true
+}
@@ -0,0 +1,264 @@
+package ascii_img
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+// *****************
+// TERMINAL OUTPUT
+// *****************
+
+// How many colors the terminal can show
+type ColorMode int
+
+const (
+	TrueColor ColorMode = iota // 24-bit, ESC[38;2;r;g;bm
+	Color256                   // xterm 256 colors, ESC[38;5;nm
+	Color16                    // the 16 basic colors, ESC[3xm / ESC[9xm
+)
+
+// Parses "truecolor" (or "24bit"), "256" or "16"
+func ParseColorMode(s string) (ColorMode, error) {
+	switch strings.ToLower(s) {
+	case "truecolor", "24bit", "24-bit":
+		return TrueColor, nil
+	case "256":
+		return Color256, nil
+	case "16":
+		return Color16, nil
+	}
+
+	return TrueColor, fmt.Errorf("unknown color mode %q, use truecolor, 256 or 16", s)
+}
+
+func (mode ColorMode) String() string {
+	switch mode {
+	case Color256:
+		return "256"
+	case Color16:
+		return "16"
+	default:
+		return "truecolor"
+	}
+}
+
+type ANSIOptions struct {
+	// colors available, TrueColor by default. Color256 and Color16 pick the perceptually closest color (OKLab) of the palette.
+	Mode ColorMode
+	// fill the cell behind each character with its pixel's color, characters are then drawn in black or white (whichever stands out)
+	Background bool
+	// with Color256 or Color16, spread each cell's color error onto its neighbours (Floyd-Steinberg) so gradients don't band
+	Dither bool
+}
+
+// a color as the terminal sees it, rgb for TrueColor, otherwise an xterm palette index
+type termColor struct {
+	rgb   [3]uint8
+	index int
+}
+
+// current colors of the terminal, so runs of the same color are only written once
+type ansiState struct {
+	fg, bg         termColor
+	has_fg, has_bg bool
+}
+
+type ansiWriter struct {
+	opts  ANSIOptions
+	pal   *palette
+	cache map[[3]uint8]int
+
+	// dithering error of the current and next row
+	err_cur, err_next []oklab
+}
+
+/*
+Writes the array as text colored with ANSI escape sequences, see ANSIOptions. Escape sequences are only written when the color
+changes and colors are reset at the end of every line. Cells without a character (rune 0, ex: XDoG) are drawn as solid blocks.
+*/
+func WriteANSI(w io.Writer, arr [][]transforms.Pixel, opts ANSIOptions) error {
+	aw := &ansiWriter{opts: opts, cache: make(map[[3]uint8]int)}
+	switch opts.Mode {
+	case Color256:
+		aw.pal = xterm256
+	case Color16:
+		aw.pal = xterm16
+	}
+
+	bw := bufio.NewWriter(w)
+	seq := make([]byte, 0, 64)
+
+	for i := range len(arr) {
+		aw.nextRow(len(arr[i]))
+
+		var state ansiState
+		for j := range len(arr[i]) {
+			cur := &arr[i][j]
+			pix := aw.color([3]uint8{cur.R, cur.G, cur.B}, j)
+
+			char := cur.Character
+			var fg, bg termColor
+			set_bg := false
+			switch {
+			case char == 0 && opts.Background:
+				char = ' '
+				fg, bg, set_bg = pix, pix, true
+			case char == 0:
+				char = '█'
+				fg = pix
+			case opts.Background:
+				fg, bg, set_bg = aw.contrastColor(cur), pix, true
+			default:
+				fg = pix
+			}
+
+			seq = state.update(seq[:0], fg, bg, set_bg, opts.Mode)
+			bw.Write(seq)
+			bw.WriteRune(char)
+		}
+
+		if len(arr[i]) > 0 {
+			bw.WriteString("\x1b[0m")
+		}
+		bw.WriteRune('\n')
+	}
+
+	return bw.Flush()
+}
+
+// moves the dithering error down a row
+func (aw *ansiWriter) nextRow(width int) {
+	if !aw.opts.Dither || aw.pal == nil {
+		return
+	}
+
+	aw.err_cur, aw.err_next = aw.err_next, aw.err_cur
+	if len(aw.err_cur) < width {
+		aw.err_cur = make([]oklab, width)
+	}
+	if len(aw.err_next) < width {
+		aw.err_next = make([]oklab, width)
+	}
+	clear(aw.err_next)
+}
+
+// maps the color of the cell in column j onto the terminal's palette
+func (aw *ansiWriter) color(c [3]uint8, j int) termColor {
+	if aw.pal == nil {
+		return termColor{rgb: c}
+	}
+
+	if !aw.opts.Dither {
+		return aw.nearest(c)
+	}
+
+	want := toOKLab(c)
+	for k := range want {
+		want[k] += aw.err_cur[j][k]
+	}
+
+	i := aw.pal.nearest(want)
+	got := aw.pal.lab[i]
+
+	width := len(aw.err_cur)
+	for k := range want {
+		diff := want[k] - got[k]
+		if j+1 < width {
+			aw.err_cur[j+1][k] += diff * 7 / 16
+			aw.err_next[j+1][k] += diff * 1 / 16
+		}
+		if j > 0 {
+			aw.err_next[j-1][k] += diff * 3 / 16
+		}
+		aw.err_next[j][k] += diff * 5 / 16
+	}
+
+	return termColor{rgb: aw.pal.rgb[i], index: aw.pal.first + i}
+}
+
+func (aw *ansiWriter) nearest(c [3]uint8) termColor {
+	if aw.pal == nil {
+		return termColor{rgb: c}
+	}
+
+	i, ok := aw.cache[c]
+	if !ok {
+		i = aw.pal.nearest(toOKLab(c))
+		aw.cache[c] = i
+	}
+
+	return termColor{rgb: aw.pal.rgb[i], index: aw.pal.first + i}
+}
+
+// black on light pixels, white on dark ones
+func (aw *ansiWriter) contrastColor(p *transforms.Pixel) termColor {
+	if transforms.Luminance(p) > 127 {
+		return aw.nearest([3]uint8{0, 0, 0})
+	}
+
+	return aw.nearest([3]uint8{255, 255, 255})
+}
+
+// appends the escape sequence moving the terminal to fg (and bg if set_bg) to seq, nothing if it's already there
+func (state *ansiState) update(seq []byte, fg termColor, bg termColor, set_bg bool, mode ColorMode) []byte {
+	change_fg := !state.has_fg || state.fg != fg
+	change_bg := set_bg && (!state.has_bg || state.bg != bg)
+
+	if !change_fg && !change_bg {
+		return seq
+	}
+
+	seq = append(seq, "\x1b["...)
+	if change_fg {
+		seq = appendColor(seq, fg, false, mode)
+		state.fg, state.has_fg = fg, true
+	}
+	if change_bg {
+		if change_fg {
+			seq = append(seq, ';')
+		}
+		seq = appendColor(seq, bg, true, mode)
+		state.bg, state.has_bg = bg, true
+	}
+
+	return append(seq, 'm')
+}
+
+// appends the SGR parameters for c, without the ESC[ and m
+func appendColor(seq []byte, c termColor, background bool, mode ColorMode) []byte {
+	switch mode {
+	case Color16:
+		code := 30 + c.index
+		if c.index >= 8 {
+			code = 90 + c.index - 8
+		}
+		if background {
+			code += 10
+		}
+		return strconv.AppendInt(seq, int64(code), 10)
+	case Color256:
+		if background {
+			seq = append(seq, "48;5;"...)
+		} else {
+			seq = append(seq, "38;5;"...)
+		}
+		return strconv.AppendInt(seq, int64(c.index), 10)
+	}
+
+	if background {
+		seq = append(seq, "48;2;"...)
+	} else {
+		seq = append(seq, "38;2;"...)
+	}
+	seq = strconv.AppendUint(seq, uint64(c.rgb[0]), 10)
+	seq = append(seq, ';')
+	seq = strconv.AppendUint(seq, uint64(c.rgb[1]), 10)
+	seq = append(seq, ';')
+	return strconv.AppendUint(seq, uint64(c.rgb[2]), 10)
+}
@@ -0,0 +1,123 @@
+package ascii_img
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+func ansiPixel(r uint8, g uint8, b uint8, char rune) transforms.Pixel {
+	return transforms.Pixel{R: r, G: g, B: b, A: 255, Character: char}
+}
+
+func TestWriteANSI(t *testing.T) {
+	arr := [][]transforms.Pixel{
+		{ansiPixel(255, 0, 0, 'a'), ansiPixel(255, 0, 0, 'b'), ansiPixel(0, 0, 255, 'c')},
+		{},
+		{ansiPixel(0, 255, 0, 0), ansiPixel(0, 255, 0, 'd')},
+	}
+	background := [][]transforms.Pixel{
+		{ansiPixel(255, 0, 0, 'a'), ansiPixel(255, 0, 0, 'b'), ansiPixel(255, 255, 255, 'c'), ansiPixel(255, 255, 255, 0)},
+	}
+
+	tests := []struct {
+		name string
+		arr  [][]transforms.Pixel
+		opts ANSIOptions
+		want string
+	}{
+		{
+			"truecolor runs", arr, ANSIOptions{},
+			"\x1b[38;2;255;0;0mab\x1b[38;2;0;0;255mc\x1b[0m\n" +
+				"\n" +
+				"\x1b[38;2;0;255;0m█d\x1b[0m\n",
+		},
+		{
+			"256 runs", arr, ANSIOptions{Mode: Color256},
+			"\x1b[38;5;196mab\x1b[38;5;21mc\x1b[0m\n" +
+				"\n" +
+				"\x1b[38;5;46m█d\x1b[0m\n",
+		},
+		{
+			"16 runs", arr, ANSIOptions{Mode: Color16},
+			"\x1b[91mab\x1b[34mc\x1b[0m\n" +
+				"\n" +
+				"\x1b[92m█d\x1b[0m\n",
+		},
+		{
+			// white characters on the dark red, black on white, and the empty cell a space in its own color
+			"truecolor background", background, ANSIOptions{Background: true},
+			"\x1b[38;2;255;255;255;48;2;255;0;0mab\x1b[38;2;0;0;0;48;2;255;255;255mc\x1b[38;2;255;255;255m \x1b[0m\n",
+		},
+		{
+			"16 background", background, ANSIOptions{Mode: Color16, Background: true},
+			"\x1b[97;101mab\x1b[30;107mc\x1b[97m \x1b[0m\n",
+		},
+	}
+
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := WriteANSI(&out, test.arr, test.opts); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("%s:\n got %q\nwant %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestPaletteNearest(t *testing.T) {
+	tests := []struct {
+		rgb          [3]uint8
+		xterm16      int
+		xterm256     int
+		xterm16_name string
+	}{
+		{[3]uint8{0, 0, 0}, 0, 16, "black"},
+		{[3]uint8{255, 0, 0}, 9, 196, "bright red"},
+		{[3]uint8{0, 255, 0}, 10, 46, "bright green"},
+		{[3]uint8{0, 0, 255}, 4, 21, "blue"},
+		{[3]uint8{255, 255, 0}, 11, 226, "bright yellow"},
+		{[3]uint8{0, 255, 255}, 14, 51, "bright cyan"},
+		{[3]uint8{255, 0, 255}, 13, 201, "bright magenta"},
+		{[3]uint8{255, 255, 255}, 15, 231, "bright white"},
+	}
+
+	for _, test := range tests {
+		lab := toOKLab(test.rgb)
+		if got := xterm16.first + xterm16.nearest(lab); got != test.xterm16 {
+			t.Errorf("%v: xterm16 color %v, want %v (%s)", test.rgb, got, test.xterm16, test.xterm16_name)
+		}
+		if got := xterm256.first + xterm256.nearest(lab); got != test.xterm256 {
+			t.Errorf("%v: xterm256 color %v, want %v", test.rgb, got, test.xterm256)
+		}
+	}
+}
+
+func TestWriteANSIDither(t *testing.T) {
+	// dark gray sits between black and xterm's gray, plain matching gives a single color and dithering a mix of both
+	row := make([]transforms.Pixel, 32)
+	for i := range row {
+		row[i] = ansiPixel(64, 64, 64, '#')
+	}
+	arr := [][]transforms.Pixel{row, row}
+
+	var plain, dithered bytes.Buffer
+	if err := WriteANSI(&plain, arr, ANSIOptions{Mode: Color16}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteANSI(&dithered, arr, ANSIOptions{Mode: Color16, Dither: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(plain.String(), "\x1b[3") + strings.Count(plain.String(), "\x1b[9"); n != 2 {
+		t.Errorf("undithered rows changed color %v times, want once per row:\n%q", n, plain.String())
+	}
+	for _, code := range []string{"\x1b[30m", "\x1b[90m"} {
+		if !strings.Contains(dithered.String(), code) {
+			t.Errorf("dithered rows never use %q:\n%q", code, dithered.String())
+		}
+	}
+}
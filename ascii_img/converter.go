@@ -0,0 +1,355 @@
+package ascii_img
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math"
+	"sync"
+	"time"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"github.com/golang/freetype/truetype"
+)
+
+// A Filter turns the sampled array into ascii characters, mapping luminance onto ramp. It may modify arr in place and return it, or return a new array.
+type Filter func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error)
+
+// The image a grid was sampled from: cell (bx, by) covers Image's pixels from bx*StrideX to (bx+1)*StrideX (see transforms.StrideSpan), likewise for y
+type Source struct {
+	Image   image.Image
+	StrideX float64
+	StrideY float64
+}
+
+// A SourceFilter is a Filter that also reads the full resolution source of the grid
+type SourceFilter func(src Source, arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error)
+
+// transforms.AsciiFilter: luminance ramp with DoG + sobel edges
+func AsciiFilter(blur_1 int, blur_2 int) Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		return arr, transforms.AsciiFilter(arr, ramp, blur_1, blur_2)
+	}
+}
+
+// transforms.AsciiFilterEdges: luminance ramp with edges found as opts says (DoG + sobel or Canny)
+func AsciiFilterEdges(opts transforms.EdgeOptions) Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		return arr, transforms.AsciiFilterEdges(arr, ramp, opts)
+	}
+}
+
+// transforms.NaiveAsciiFilter: luminance ramp with sobel edges, no DoG
+func NaiveAsciiFilter() Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		return arr, transforms.NaiveAsciiFilter(arr, ramp)
+	}
+}
+
+// transforms.NaiveAsciiFilterEdges: NaiveAsciiFilter with opts' gradient operator, cutoff threshold and edge orientation
+func NaiveAsciiFilterEdges(opts transforms.EdgeOptions) Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		return arr, transforms.NaiveAsciiFilterEdges(arr, ramp, opts)
+	}
+}
+
+// transforms.NoEdgesFilter: luminance ramp with sobel edges
+func NoEdgesFilter() Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		return arr, transforms.NoEdgesFilter(arr, ramp)
+	}
+}
+
+// transforms.NoEdgesFilterEdges: NoEdgesFilter with opts' gradient operator, cutoff threshold and edge orientation
+func NoEdgesFilterEdges(opts transforms.EdgeOptions) Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		return arr, transforms.NoEdgesFilterEdges(arr, ramp, opts)
+	}
+}
+
+// transforms.XDoG: leaves no characters behind, so cells are drawn solid in their color
+func XDoGFilter() Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		return transforms.XDoG(arr)
+	}
+}
+
+// transforms.LuminFilter: luminance ramp only
+func LuminFilter() Filter {
+	return func(arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		transforms.LuminFilter(arr, ramp)
+		return arr, nil
+	}
+}
+
+/*
+transforms.FullResAsciiFilter: luminance ramp with DoG + sobel edges found on the full resolution image, each cell taking the edge
+direction at least threshold (0-1) of its pixels agree on
+*/
+func FullResAsciiFilter(blur_1 int, blur_2 int, threshold float64) SourceFilter {
+	return func(src Source, arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		full := PlaneFromImage(src.Image)
+		return arr, transforms.FullResAsciiFilter(arr, full, ramp, blur_1, blur_2, src.StrideX, src.StrideY, threshold)
+	}
+}
+
+// FullResAsciiFilter with the edges found as opts says
+func FullResAsciiFilterEdges(opts transforms.EdgeOptions, threshold float64) SourceFilter {
+	return func(src Source, arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		full := PlaneFromImage(src.Image)
+		return arr, transforms.FullResAsciiFilterEdges(arr, full, ramp, opts, src.StrideX, src.StrideY, threshold)
+	}
+}
+
+// *****************
+// CONVERTER
+// *****************
+
+// Converter runs the whole image -> ascii pipeline (sample, filter, draw). Create one with NewConverter, it is safe to reuse.
+type Converter struct {
+	size        Size
+	resampler   Resampler
+	cell_size   int
+	cell_aspect float64 // cell width over height, 0 takes it from the font
+	filter      SourceFilter
+	ramp        transforms.Ramp
+	font        *truetype.Font
+	color       bool
+	render      bool
+
+	// measured ramp, see WithMeasuredRamp
+	measure        bool
+	measure_levels int
+	measure_once   sync.Once
+	measured       transforms.Ramp
+	measure_err    error
+}
+
+type Option func(*Converter)
+
+// Average every NxN block of the source image into one character (default 8)
+func WithSampleSize(sample_size int) Option {
+	return func(c *Converter) { c.size = BySample(float64(sample_size)) }
+}
+
+// Pick the grid size by columns, rows, a box or the terminal instead of a sample size, see Size. Replaces WithSampleSize.
+func WithSize(size Size) Option {
+	return func(c *Converter) { c.size = size }
+}
+
+/*
+How the image is sampled into the grid (default ResampleBox, whole pixels averaged, the last row and column averaging what's left over).
+The other resamplers stretch the grid over the image with fractional weights, see ResampleArray.
+*/
+func WithResampler(resampler Resampler) Option {
+	return func(c *Converter) { c.resampler = resampler }
+}
+
+// Draw every character into a px_size x px_size cell of the output image (default 8)
+func WithCellSize(px_size int) Option {
+	return func(c *Converter) { c.cell_size = px_size }
+}
+
+/*
+Shape of the cells characters are drawn in, width over height (default 1, square). Samples are stretched to match so the art keeps the
+image's proportions: use 0.5 for text shown in a terminal. 0 uses the proportions of the font's characters, see GlyphAspect.
+*/
+func WithCellAspect(aspect float64) Option {
+	return func(c *Converter) { c.cell_aspect = aspect }
+}
+
+// Filter used to pick characters (default AsciiFilter(1, 15))
+func WithFilter(filter Filter) Option {
+	return func(c *Converter) {
+		c.filter = func(src Source, arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+			return filter(arr, ramp)
+		}
+	}
+}
+
+// Filter that also reads the full resolution image, ex: FullResAsciiFilter. Replaces WithFilter.
+func WithSourceFilter(filter SourceFilter) Option {
+	return func(c *Converter) { c.filter = filter }
+}
+
+// Characters handed to the filter, least dense first (default transforms.StandardRamp())
+func WithRamp(ramp transforms.Ramp) Option {
+	return func(c *Converter) { c.ramp = ramp }
+}
+
+// Build the ramp from charset by measuring each character's coverage in the converter's font, in cells of its size and aspect, see MeasureRampCell.
+// Replaces WithRamp. The ramp is measured once, on the first Convert.
+func WithMeasuredRamp(charset transforms.Ramp, levels int) Option {
+	return func(c *Converter) {
+		c.ramp = charset
+		c.measure = true
+		c.measure_levels = levels
+	}
+}
+
+// Font the output image is drawn with (default DefaultFont()), see BundledFont and LoadFont
+func WithFont(font *truetype.Font) Option {
+	return func(c *Converter) { c.font = font }
+}
+
+// Draw characters in the color of the image instead of white (default true)
+func WithColor(color bool) Option {
+	return func(c *Converter) { c.color = color }
+}
+
+// Draw the output image, turn off when only the characters are needed (default true)
+func WithRender(render bool) Option {
+	return func(c *Converter) { c.render = render }
+}
+
+func NewConverter(opts ...Option) *Converter {
+	c := &Converter{
+		size:        BySample(8),
+		cell_size:   8,
+		cell_aspect: 1,
+		ramp:        transforms.StandardRamp(),
+		color:       true,
+		render:      true,
+	}
+	WithFilter(AsciiFilter(1, 15))(c)
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// How long each stage of Convert took
+type Timings struct {
+	Sample time.Duration
+	Filter time.Duration
+	Render time.Duration
+	Total  time.Duration
+}
+
+type Result struct {
+	Grid    [][]transforms.Pixel
+	Image   *image.RGBA // nil when rendering is turned off
+	Timings Timings
+}
+
+// Converts img into ascii art. ctx is checked between stages.
+func (c *Converter) Convert(ctx context.Context, img image.Image) (*Result, error) {
+	if c.cell_size < 1 {
+		return nil, fmt.Errorf("%w: cell size %v", ErrInvalidSize, c.cell_size)
+	}
+
+	if c.cell_aspect < 0 {
+		return nil, fmt.Errorf("%w: cell aspect %v", ErrInvalidSize, c.cell_aspect)
+	}
+
+	aspect, err := c.aspect()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	start := time.Now()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if img == nil {
+		return nil, transforms.ErrEmptyImage
+	}
+
+	bounds := img.Bounds()
+	columns, rows, stride_x, stride_y, err := c.size.Grid(bounds.Dx(), bounds.Dy(), aspect)
+	if err != nil {
+		return nil, err
+	}
+
+	var arr [][]transforms.Pixel
+	if c.resampler == ResampleBox {
+		arr = InitializeArrayStride(img, stride_x, stride_y, rows, columns)
+	} else {
+		// resamplers stretch the grid over the whole image
+		arr = ResampleArray(img, columns, rows, c.resampler)
+		stride_x, stride_y = float64(bounds.Dx())/float64(columns), float64(bounds.Dy())/float64(rows)
+	}
+
+	res.Timings.Sample = time.Since(start)
+	intermediate := time.Now()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ramp, err := c.activeRamp(aspect)
+	if err != nil {
+		return nil, err
+	}
+
+	arr, err = c.filter(Source{img, stride_x, stride_y}, arr, ramp)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Grid = arr
+	res.Timings.Filter = time.Since(intermediate)
+	intermediate = time.Now()
+
+	if c.render {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		cell_width := max(1, int(math.Round(float64(c.cell_size)*aspect)))
+		res.Image, err = OutputImageCells(arr, cell_width, c.cell_size, c.color, c.font)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Timings.Render = time.Since(intermediate)
+	}
+
+	res.Timings.Total = time.Since(start)
+
+	return res, nil
+}
+
+// width over height of the cells, measured from the font when cell_aspect is 0
+func (c *Converter) aspect() (float64, error) {
+	if c.cell_aspect > 0 {
+		return c.cell_aspect, nil
+	}
+
+	font := c.font
+	if font == nil {
+		var err error
+		if font, err = DefaultFont(); err != nil {
+			return 0, err
+		}
+	}
+
+	return GlyphAspect(font, c.cell_size), nil
+}
+
+// ramp to filter with, measured in cells of the aspect the image is drawn with
+func (c *Converter) activeRamp(aspect float64) (transforms.Ramp, error) {
+	if !c.measure {
+		return c.ramp, nil
+	}
+
+	c.measure_once.Do(func() {
+		font := c.font
+		if font == nil {
+			font, c.measure_err = DefaultFont()
+			if c.measure_err != nil {
+				return
+			}
+		}
+
+		cell_width := max(1, int(math.Round(float64(c.cell_size)*aspect)))
+		c.measured, c.measure_err = MeasureRampCell(font, cell_width, c.cell_size, c.ramp, c.measure_levels)
+	})
+
+	return c.measured, c.measure_err
+}
@@ -0,0 +1,151 @@
+package ascii_img
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Every printable ascii character, used by MeasureRamp when no charset is given
+func PrintableASCII() transforms.Ramp {
+	ramp := make(transforms.Ramp, 0, 95)
+	for r := rune(32); r < 127; r++ {
+		ramp = append(ramp, r)
+	}
+
+	return ramp
+}
+
+// Draws r the same way OutputImage does (white, baseline at the bottom of a cell_size x cell_size cell) and returns the fraction of the cell it covers, 0-1.
+func GlyphCoverage(font *truetype.Font, cell_size int, r rune) (float64, error) {
+	return GlyphCoverageCell(font, cell_size, cell_size, r)
+}
+
+// Same as GlyphCoverage in a cell_width x cell_height cell, the glyph drawn cell_height tall like OutputImageCells does.
+func GlyphCoverageCell(font *truetype.Font, cell_width int, cell_height int, r rune) (float64, error) {
+	if cell_width < 1 || cell_height < 1 {
+		return 0, fmt.Errorf("%w: cell size %vx%v", ErrInvalidSize, cell_width, cell_height)
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, cell_width, cell_height))
+
+	c := freetype.NewContext()
+	c.SetDPI(72)
+	c.SetFont(font)
+	c.SetFontSize(float64(cell_height))
+	c.SetClip(mask.Bounds())
+	c.SetDst(mask)
+	c.SetSrc(image.Opaque)
+
+	if _, err := c.DrawString(string(r), fixed.P(0, cell_height)); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, a := range mask.Pix {
+		total += int(a)
+	}
+
+	return float64(total) / float64(255*cell_width*cell_height), nil
+}
+
+// perceived lightness (CIE L*, 0-1) of a cell covered by the fraction coverage of white
+func coverageLightness(coverage float64) float64 {
+	if coverage <= 216.0/24389.0 {
+		return coverage * 24389.0 / 27.0 / 100
+	}
+
+	return (116*math.Cbrt(coverage) - 16) / 100
+}
+
+type glyphCoverage struct {
+	r         rune
+	lightness float64
+}
+
+/*
+Builds a ramp for font by measuring how much of a cell_size cell every character of charset covers. Characters the font doesn't have are
+skipped. The result has at most levels characters, picked so their perceived lightness rises in even steps; levels <= 0 keeps every
+character, only sorting them, and levels == 1 is an ErrInvalidRamp. A nil charset measures PrintableASCII().
+*/
+func MeasureRamp(font *truetype.Font, cell_size int, charset transforms.Ramp, levels int) (transforms.Ramp, error) {
+	return MeasureRampCell(font, cell_size, cell_size, charset, levels)
+}
+
+// Same as MeasureRamp with coverage measured in cell_width x cell_height cells, see GlyphCoverageCell
+func MeasureRampCell(font *truetype.Font, cell_width int, cell_height int, charset transforms.Ramp, levels int) (transforms.Ramp, error) {
+	if font == nil {
+		return nil, fmt.Errorf("%w: no font to measure", ErrFontLoad)
+	}
+
+	if levels == 1 {
+		return nil, fmt.Errorf("%w: a ramp needs at least 2 levels", transforms.ErrInvalidRamp)
+	}
+
+	if charset == nil {
+		charset = PrintableASCII()
+	}
+
+	measured := make([]glyphCoverage, 0, len(charset))
+	seen := make(map[rune]bool, len(charset))
+	for _, r := range charset {
+		if seen[r] || (r != ' ' && font.Index(r) == 0) {
+			continue
+		}
+		seen[r] = true
+
+		coverage, err := GlyphCoverageCell(font, cell_width, cell_height, r)
+		if err != nil {
+			return nil, err
+		}
+
+		measured = append(measured, glyphCoverage{r, coverageLightness(coverage)})
+	}
+
+	if len(measured) < 2 {
+		return nil, fmt.Errorf("%w: font has fewer than 2 characters of %q", transforms.ErrInvalidRamp, charset.String())
+	}
+
+	sort.SliceStable(measured, func(i, j int) bool {
+		return measured[i].lightness < measured[j].lightness
+	})
+
+	if levels <= 0 || levels >= len(measured) {
+		ramp := make(transforms.Ramp, len(measured))
+		for i, g := range measured {
+			ramp[i] = g.r
+		}
+
+		return ramp, nil
+	}
+
+	// walk evenly spaced lightness targets, taking the closest glyph that comes after the last one picked. Glyphs are white on black,
+	// so the least covered glyph makes the darkest cell.
+	darkest := measured[0].lightness
+	lightest := measured[len(measured)-1].lightness
+	ramp := make(transforms.Ramp, 0, levels)
+	last := -1
+	for level := range levels {
+		target := darkest + (lightest-darkest)*float64(level)/float64(levels-1)
+
+		// leave enough glyphs for the levels still to come
+		remaining := levels - level - 1
+		best := last + 1
+		for i := best + 1; i < len(measured)-remaining; i++ {
+			if math.Abs(measured[i].lightness-target) < math.Abs(measured[best].lightness-target) {
+				best = i
+			}
+		}
+
+		ramp = append(ramp, measured[best].r)
+		last = best
+	}
+
+	return ramp, nil
+}
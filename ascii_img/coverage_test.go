@@ -0,0 +1,57 @@
+package ascii_img
+
+import (
+	"errors"
+	"testing"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+func TestMeasureRamp(t *testing.T) {
+	font, err := DefaultFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, levels := range []int{0, 2, 5, 10, 1000} {
+		ramp, err := MeasureRampCell(font, 8, 16, nil, levels)
+		if err != nil {
+			t.Fatalf("%v levels: %v", levels, err)
+		}
+
+		if levels > 0 && len(ramp) > levels {
+			t.Errorf("%v levels made a ramp of %v characters", levels, len(ramp))
+		}
+		if levels > 0 && levels < 95 && len(ramp) != levels {
+			t.Errorf("%v levels made a ramp of %v characters, the font has enough for all of them", levels, len(ramp))
+		}
+
+		// darkest first, every character at least as light as the one before
+		last := -1.0
+		for _, r := range ramp {
+			coverage, err := GlyphCoverageCell(font, 8, 16, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if lightness := coverageLightness(coverage); lightness < last {
+				t.Errorf("%v levels: %q is darker than the character before it in %q", levels, r, ramp.String())
+			} else {
+				last = lightness
+			}
+		}
+	}
+}
+
+func TestMeasureRampInvalid(t *testing.T) {
+	font, err := DefaultFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := MeasureRamp(font, 8, nil, 1); !errors.Is(err, transforms.ErrInvalidRamp) {
+		t.Errorf("1 level: got %v, want ErrInvalidRamp", err)
+	}
+	if _, err := MeasureRamp(font, 8, transforms.Ramp("@"), 0); !errors.Is(err, transforms.ErrInvalidRamp) {
+		t.Errorf("one character: got %v, want ErrInvalidRamp", err)
+	}
+}
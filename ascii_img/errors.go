@@ -0,0 +1,16 @@
+package ascii_img
+
+import "errors"
+
+var (
+	// returned (wrapped) when an image isn't in a registered format (png, jpeg, gif)
+	ErrUnsupportedFormat = errors.New("ascii_img: unsupported image format")
+	// returned (wrapped) when an image of a supported format is truncated or corrupt
+	ErrDecode = errors.New("ascii_img: could not decode image")
+	// returned (wrapped) when a font can't be read or parsed
+	ErrFontLoad = errors.New("ascii_img: could not load font")
+	// returned (wrapped) when a sample or pixel size is smaller than 1
+	ErrInvalidSize = errors.New("ascii_img: invalid size")
+	// returned by TerminalSize when there is no terminal to size the output to
+	ErrNoTerminal = errors.New("ascii_img: terminal size unavailable")
+)
@@ -0,0 +1,164 @@
+package ascii_img
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	fonts "github.com/RohanPalivela/ascii_image_manip/Fonts"
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+)
+
+// font drawn with when none is picked
+const DefaultFontName = "MC"
+
+// every font is parsed once, keyed by "bundled:<name>" or "file:<absolute path>"
+var font_cache = struct {
+	sync.Mutex
+	fonts map[string]*truetype.Font
+}{fonts: make(map[string]*truetype.Font)}
+
+func cachedFont(key string, load func() (*truetype.Font, error)) (*truetype.Font, error) {
+	font_cache.Lock()
+	defer font_cache.Unlock()
+
+	if f, ok := font_cache.fonts[key]; ok {
+		return f, nil
+	}
+
+	f, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	font_cache.fonts[key] = f
+
+	return f, nil
+}
+
+// Names of the fonts bundled into the binary (see the Fonts directory)
+func BundledFonts() []string {
+	return fonts.Names()
+}
+
+// Returns the bundled font called name (ex: "MC", "Mont", "BoldPixelsFont"), parsing it on first use.
+func BundledFont(name string) (*truetype.Font, error) {
+	fontBytes, ok := fonts.Bytes(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: no bundled font %q, have %v", ErrFontLoad, name, BundledFonts())
+	}
+
+	return cachedFont("bundled:"+name, func() (*truetype.Font, error) {
+		return ParseFont(fontBytes)
+	})
+}
+
+// The bundled DefaultFontName font
+func DefaultFont() (*truetype.Font, error) {
+	return BundledFont(DefaultFontName)
+}
+
+// Parses TrueType font bytes. Not cached, keep the returned font around to reuse it.
+func ParseFont(fontBytes []byte) (*truetype.Font, error) {
+	f, err := freetype.ParseFont(fontBytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFontLoad, err)
+	}
+
+	return f, nil
+}
+
+// Reads and parses the TrueType font at path, parsing each file only once.
+func LoadFontFile(path string) (*truetype.Font, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFontLoad, err)
+	}
+
+	return cachedFont("file:"+abs, func() (*truetype.Font, error) {
+		fontBytes, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFontLoad, err)
+		}
+
+		f, err := ParseFont(fontBytes)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", path, err)
+		}
+
+		return f, nil
+	})
+}
+
+// Loads the bundled font called name_or_path if there is one, otherwise the TrueType file at that path.
+func LoadFont(name_or_path string) (*truetype.Font, error) {
+	if _, ok := fonts.Bytes(name_or_path); ok {
+		return BundledFont(name_or_path)
+	}
+
+	return LoadFontFile(name_or_path)
+}
+
+// Raw TrueType bytes of the bundled font called name_or_path if there is one, otherwise of the file at that path. Used to embed fonts in exports.
+func FontBytes(name_or_path string) ([]byte, error) {
+	if fontBytes, ok := fonts.Bytes(name_or_path); ok {
+		return fontBytes, nil
+	}
+
+	fontBytes, err := os.ReadFile(name_or_path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFontLoad, err)
+	}
+
+	return fontBytes, nil
+}
+
+// most atlases kept at once, the least recently used one is dropped past it
+const max_atlases = 16
+
+/*
+every font's atlas is rasterized once per size. Fonts are told apart by pointer: BundledFont and LoadFontFile hand out one font per
+file, fonts from ParseFont are new every time and get evicted once they stop being drawn with.
+*/
+var atlas_cache = struct {
+	sync.Mutex
+	atlases map[atlasKey]*list.Element // of *atlasEntry, front is the most recently used
+	order   *list.List
+}{atlases: make(map[atlasKey]*list.Element), order: list.New()}
+
+type atlasKey struct {
+	font    *truetype.Font
+	px_size int
+}
+
+type atlasEntry struct {
+	key   atlasKey
+	atlas *transforms.GlyphAtlas
+}
+
+// Pre-rasterized glyphs of font drawn px_size tall, shared by every render at that size. The standard ramp is rasterized up front.
+func GlyphAtlas(font *truetype.Font, px_size int) *transforms.GlyphAtlas {
+	atlas_cache.Lock()
+	defer atlas_cache.Unlock()
+
+	key := atlasKey{font, px_size}
+	if element, ok := atlas_cache.atlases[key]; ok {
+		atlas_cache.order.MoveToFront(element)
+		return element.Value.(*atlasEntry).atlas
+	}
+
+	atlas := transforms.NewGlyphAtlas(font, float64(px_size), transforms.StandardRamp())
+	atlas_cache.atlases[key] = atlas_cache.order.PushFront(&atlasEntry{key, atlas})
+
+	for atlas_cache.order.Len() > max_atlases {
+		oldest := atlas_cache.order.Back()
+		atlas_cache.order.Remove(oldest)
+		delete(atlas_cache.atlases, oldest.Value.(*atlasEntry).key)
+	}
+
+	return atlas
+}
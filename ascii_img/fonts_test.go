@@ -0,0 +1,56 @@
+package ascii_img
+
+import (
+	"container/list"
+	"testing"
+)
+
+func TestGlyphAtlasCacheEvicts(t *testing.T) {
+	font, err := DefaultFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	atlas_cache.Lock()
+	atlas_cache.atlases = make(map[atlasKey]*list.Element)
+	atlas_cache.order = list.New()
+	atlas_cache.Unlock()
+
+	first := GlyphAtlas(font, 1)
+	second := GlyphAtlas(font, 2)
+	for px_size := 3; px_size <= max_atlases; px_size++ {
+		GlyphAtlas(font, px_size)
+	}
+
+	// size 1 is now the least recently used, one more atlas drops it instead of size 2
+	if GlyphAtlas(font, 1) != first {
+		t.Fatal("atlas wasn't cached")
+	}
+	GlyphAtlas(font, max_atlases+1)
+
+	if got := len(atlas_cache.atlases); got != max_atlases || atlas_cache.order.Len() != max_atlases {
+		t.Fatalf("cache holds %v atlases, want %v", got, max_atlases)
+	}
+	if GlyphAtlas(font, 1) != first {
+		t.Error("recently used atlas was evicted")
+	}
+	if GlyphAtlas(font, 2) == second {
+		t.Error("least recently used atlas wasn't evicted")
+	}
+
+	// a font parsed again is a different font, its atlases push the old ones out instead of piling up
+	font_bytes, err := FontBytes(DefaultFontName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 3 * max_atlases {
+		parsed, err := ParseFont(font_bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		GlyphAtlas(parsed, 8)
+	}
+	if got := len(atlas_cache.atlases); got != max_atlases {
+		t.Errorf("cache holds %v atlases, want %v", got, max_atlases)
+	}
+}
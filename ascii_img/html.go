@@ -0,0 +1,190 @@
+package ascii_img
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"sort"
+	"strings"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"golang.org/x/image/math/fixed"
+)
+
+// *****************
+// HTML OUTPUT
+// *****************
+
+type HTMLOptions struct {
+	// pixels each character takes up, like OutputImage's px_size (default 8). Characters are CellSize tall.
+	CellSize int
+	// width of every cell when it isn't square, like OutputImageCells' cell_width (0 is CellSize). Match the aspect the grid was sampled with.
+	CellWidth int
+	// CSS font-family list used for the characters (default "monospace"), can't contain < > { } or ;
+	FontStack string
+	// page and <pre> background (default black)
+	Background color.Color
+	// color characters with their pixel, otherwise they're white
+	Color bool
+	// bundled font name or TrueType path to embed with @font-face as a data URI, so the page looks like OutputImage's raster. Empty embeds nothing.
+	EmbedFont string
+	// <title> of the page
+	Title string
+}
+
+// family name the embedded font is registered under
+const embeddedFontFamily = "asciify-embedded"
+
+// a run of neighbouring cells of the same color in a row
+type htmlRun struct {
+	color [3]uint8
+	text  []rune
+}
+
+/*
+Writes the array as a self-contained html page: a <pre> with one <span> per run of same colored characters. Colors used by more than
+one run get a CSS class, the rest are styled inline. Cells without a character (rune 0, ex: XDoG) are drawn as solid blocks.
+*/
+func WriteHTML(w io.Writer, arr [][]transforms.Pixel, opts HTMLOptions) error {
+	if opts.CellSize <= 0 {
+		opts.CellSize = 8
+	}
+	if opts.CellWidth <= 0 {
+		opts.CellWidth = opts.CellSize
+	}
+	if opts.FontStack == "" {
+		opts.FontStack = "monospace"
+	}
+	// written into the <style> block as is, keep it from closing the rule or the block
+	if strings.ContainsAny(opts.FontStack, "<>{};") {
+		return fmt.Errorf("font stack %q can't contain any of < > { } ;", opts.FontStack)
+	}
+	if opts.Background == nil {
+		opts.Background = color.Black
+	}
+	if opts.Title == "" {
+		opts.Title = "ascii art"
+	}
+
+	rows := make([][]htmlRun, len(arr))
+	uses := make(map[[3]uint8]int)
+	for i := range len(arr) {
+		for j := range len(arr[i]) {
+			cur := &arr[i][j]
+
+			c := [3]uint8{255, 255, 255}
+			if opts.Color {
+				c = [3]uint8{cur.R, cur.G, cur.B}
+			}
+
+			char := cur.Character
+			if char == 0 {
+				char = '█'
+			}
+
+			if n := len(rows[i]); n > 0 && rows[i][n-1].color == c {
+				rows[i][n-1].text = append(rows[i][n-1].text, char)
+				continue
+			}
+
+			rows[i] = append(rows[i], htmlRun{color: c, text: []rune{char}})
+			uses[c]++
+		}
+	}
+
+	// colors repeated across runs get a class, most used first
+	repeated := make([][3]uint8, 0, len(uses))
+	for c, n := range uses {
+		if n > 1 {
+			repeated = append(repeated, c)
+		}
+	}
+	sort.Slice(repeated, func(a, b int) bool {
+		if uses[repeated[a]] != uses[repeated[b]] {
+			return uses[repeated[a]] > uses[repeated[b]]
+		}
+		return hexColor(repeated[a]) < hexColor(repeated[b])
+	})
+	classes := make(map[[3]uint8]string, len(repeated))
+	for i, c := range repeated {
+		classes[c] = fmt.Sprintf("c%d", i)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	font_face, font_stack, letter_spacing, err := htmlFont(opts)
+	if err != nil {
+		return err
+	}
+
+	r, g, b, _ := opts.Background.RGBA()
+	background := hexColor([3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)})
+
+	fmt.Fprintf(bw, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n", html.EscapeString(opts.Title))
+	bw.WriteString(font_face)
+	fmt.Fprintf(bw, "body{margin:0;background:%s}\n", background)
+	fmt.Fprintf(bw, "pre.ascii{margin:0;background:%s;color:#ffffff;font-family:%s;font-size:%dpx;line-height:%dpx;letter-spacing:%s}\n",
+		background, font_stack, opts.CellSize, opts.CellSize, letter_spacing)
+	for _, c := range repeated {
+		fmt.Fprintf(bw, ".%s{color:%s}\n", classes[c], hexColor(c))
+	}
+	bw.WriteString("</style>\n</head>\n<body>\n<pre class=\"ascii\">")
+
+	for _, row := range rows {
+		for _, run := range row {
+			text := html.EscapeString(string(run.text))
+			switch class, ok := classes[run.color]; {
+			case !opts.Color:
+				bw.WriteString(text)
+			case ok:
+				fmt.Fprintf(bw, "<span class=\"%s\">%s</span>", class, text)
+			default:
+				fmt.Fprintf(bw, "<span style=\"color:%s\">%s</span>", hexColor(run.color), text)
+			}
+		}
+		bw.WriteRune('\n')
+	}
+
+	bw.WriteString("</pre>\n</body>\n</html>\n")
+
+	return bw.Flush()
+}
+
+/*
+the @font-face rule embedding opts.EmbedFont, the font stack using it and the letter spacing that makes its characters CellWidth wide.
+Without an embedded font the advance isn't known, non square cells are spaced relative to the font's 1ch.
+*/
+func htmlFont(opts HTMLOptions) (font_face string, font_stack string, letter_spacing string, err error) {
+	if opts.EmbedFont == "" {
+		if opts.CellWidth == opts.CellSize {
+			return "", opts.FontStack, "0.000px", nil
+		}
+		return "", opts.FontStack, fmt.Sprintf("calc(%dpx - 1ch)", opts.CellWidth), nil
+	}
+
+	font_bytes, err := FontBytes(opts.EmbedFont)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	f, err := ParseFont(font_bytes)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	advance := f.HMetric(fixed.Int26_6(opts.CellSize<<6), f.Index('M')).AdvanceWidth
+	letter_spacing = fmt.Sprintf("%.3fpx", float64(opts.CellWidth)-float64(advance)/64)
+
+	font_face = fmt.Sprintf("@font-face{font-family:\"%s\";src:url(data:font/ttf;base64,%s) format(\"truetype\")}\n",
+		embeddedFontFamily, base64.StdEncoding.EncodeToString(font_bytes))
+	font_stack = fmt.Sprintf("\"%s\",%s", embeddedFontFamily, opts.FontStack)
+
+	return font_face, font_stack, letter_spacing, nil
+}
+
+func hexColor(c [3]uint8) string {
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
@@ -0,0 +1,82 @@
+package ascii_img
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+func htmlTestArray() [][]transforms.Pixel {
+	red := transforms.Pixel{R: 255, A: 255}
+	blue := transforms.Pixel{B: 255, A: 255}
+	green := transforms.Pixel{G: 255, A: 255}
+
+	with := func(p transforms.Pixel, char rune) transforms.Pixel {
+		p.Character = char
+		return p
+	}
+
+	return [][]transforms.Pixel{
+		{with(red, 'a'), with(red, 'b'), with(blue, '<'), with(red, '&')},
+		{with(green, 'x'), with(green, 0), with(green, 'y'), with(green, 'z')},
+	}
+}
+
+func TestWriteHTMLRuns(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteHTML(&out, htmlTestArray(), HTMLOptions{Color: true}); err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+
+	for _, want := range []string{
+		// red has two runs so it gets a class, blue and green only one so they're inline
+		".c0{color:#ff0000}\n",
+		`<span class="c0">ab</span><span style="color:#0000ff">&lt;</span><span class="c0">&amp;</span>` + "\n",
+		// cells without a character are solid blocks merged into the run
+		`<span style="color:#00ff00">x█yz</span>` + "\n",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("html is missing %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, ".c1") {
+		t.Errorf("colors used by a single run got a class:\n%s", page)
+	}
+}
+
+func TestWriteHTMLNoColor(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteHTML(&out, htmlTestArray(), HTMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	page := out.String()
+
+	if want := "<pre class=\"ascii\">ab&lt;&amp;\nx█yz\n</pre>"; !strings.Contains(page, want) {
+		t.Errorf("html is missing %q:\n%s", want, page)
+	}
+	if strings.Contains(page, "<span") {
+		t.Errorf("uncolored html has spans:\n%s", page)
+	}
+}
+
+func TestWriteHTMLFontStack(t *testing.T) {
+	arr := htmlTestArray()
+
+	for _, stack := range []string{"x</style><script>alert(1)</script>", "a}body{display:none", "mono;color:red"} {
+		var out bytes.Buffer
+		if err := WriteHTML(&out, arr, HTMLOptions{FontStack: stack}); err == nil {
+			t.Errorf("font stack %q was written:\n%s", stack, out.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := WriteHTML(&out, arr, HTMLOptions{FontStack: `"Fira Code", monospace`}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `font-family:"Fira Code", monospace;`; !strings.Contains(out.String(), want) {
+		t.Errorf("html is missing %q:\n%s", want, out.String())
+	}
+}
@@ -1,94 +1,228 @@
 package ascii_img
 
 import (
+	"errors"
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
+	_ "image/gif"
 	"image/jpeg"
 	"image/png"
-	"log"
+	"io"
+	"math"
 	"os"
 	"strings"
 
 	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
 	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
 )
 
-// Place your image (future: supporting video) into the "images/" directory. Provide the filename (ex: "hi.png" with no images/) into this function. A sample size N averages every NxN space, downscaling the image by Nx.
-func Initialize(filename string, sample_size int) [][]transforms.Pixel {
-	var img image.Image
-	var bounds image.Rectangle
+// Opens the image at filename and samples it, see InitializeFromReader. The format is detected from the file's contents, not its extension. A sample size N averages every NxN space, downscaling the image by Nx.
+func Initialize(filename string, sample_size int) ([][]transforms.Pixel, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	return InitializeFromReader(file, sample_size)
+}
+
+// Decodes any registered image format (png, jpeg, gif) from r, detected by its magic bytes, and samples it like InitializeFromImage.
+func InitializeFromReader(r io.Reader, sample_size int) ([][]transforms.Pixel, error) {
+	img, err := OpenImg(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return InitializeFromImage(img, sample_size)
+}
+
+// Samples an already decoded image. A sample size N averages every NxN space, downscaling the image by Nx.
+func InitializeFromImage(img image.Image, sample_size int) ([][]transforms.Pixel, error) {
+	return InitializeFromImageXY(img, sample_size, sample_size)
+}
+
+/*
+Samples an already decoded image, averaging every sample_x wide, sample_y tall block into one pixel. The last column and row average
+whatever is left over when the sample size doesn't divide the image. See SampleSizes for picking them from a cell aspect ratio.
+*/
+func InitializeFromImageXY(img image.Image, sample_x int, sample_y int) ([][]transforms.Pixel, error) {
+	if img == nil {
+		return nil, transforms.ErrEmptyImage
+	}
 
-	switch filename[strings.Index(filename, "."):] {
-	case ".png":
-		img, bounds = OpenPNGImg(filename)
-	case ".jpeg", ".jpg":
-		img, bounds = OpenJPEGImg(filename)
+	if sample_x < 1 || sample_y < 1 {
+		return nil, fmt.Errorf("%w: sample size %vx%v", ErrInvalidSize, sample_x, sample_y)
 	}
 
+	bounds := img.Bounds()
 	width := bounds.Dx()
 	height := bounds.Dy()
 
-	pix_width := width / sample_size
-	pix_height := height / sample_size
+	if sample_x > width || sample_y > height {
+		return nil, fmt.Errorf("%w: sample size %vx%v is larger than the %vx%v image", ErrInvalidSize, sample_x, sample_y, width, height)
+	}
 
-	arr := InitializeArray(img, sample_size, pix_height, pix_width)
+	// rounded up, the last block is clipped to the image
+	pix_width := (width + sample_x - 1) / sample_x
+	pix_height := (height + sample_y - 1) / sample_y
 
-	return arr
+	arr := InitializeArray(img, sample_x, sample_y, pix_height, pix_width)
+
+	return arr, nil
 }
 
-func OutputImage(arr [][]transforms.Pixel, px_size int, color_image bool) *image.RGBA {
+// Opens the image at filename and samples it into a grid sized by size, see Size.Grid. cell_aspect is the width over height of the cells the art is shown in.
+func InitializeSized(filename string, size Size, cell_aspect float64) ([][]transforms.Pixel, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+
+	defer file.Close()
+
+	img, err := OpenImg(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return InitializeFromImageSized(img, size, cell_aspect)
+}
+
+// Samples an already decoded image into a grid sized by size, see Size.Grid
+func InitializeFromImageSized(img image.Image, size Size, cell_aspect float64) ([][]transforms.Pixel, error) {
+	if img == nil {
+		return nil, transforms.ErrEmptyImage
+	}
+
+	bounds := img.Bounds()
+	columns, rows, stride_x, stride_y, err := size.Grid(bounds.Dx(), bounds.Dy(), cell_aspect)
+	if err != nil {
+		return nil, err
+	}
+
+	return InitializeArrayStride(img, stride_x, stride_y, rows, columns), nil
+}
+
+/*
+Sample sizes for characters drawn in cells cell_aspect times as wide as they are tall (terminal fonts are about 0.5). sample_size is the
+width of a block, its height is stretched so the art keeps the image's proportions. cell_aspect <= 0 means square cells.
+*/
+func SampleSizes(sample_size int, cell_aspect float64) (sample_x int, sample_y int) {
+	if cell_aspect <= 0 {
+		return sample_size, sample_size
+	}
+
+	return sample_size, max(1, int(math.Round(float64(sample_size)/cell_aspect)))
+}
+
+// Draws the array with the default font, every character taking up a px_size x px_size cell.
+func OutputImage(arr [][]transforms.Pixel, px_size int, color_image bool) (*image.RGBA, error) {
+	return OutputImageWithFont(arr, px_size, color_image, nil)
+}
+
+// Same as OutputImage, drawing with font instead. A nil font uses the default font.
+func OutputImageWithFont(arr [][]transforms.Pixel, px_size int, color_image bool, font *truetype.Font) (*image.RGBA, error) {
+	return OutputImageCells(arr, px_size, px_size, color_image, font)
+}
+
+// Draws the array in cell_width x cell_height cells, characters are drawn cell_height pixels tall. A nil font uses the default font.
+func OutputImageCells(arr [][]transforms.Pixel, cell_width int, cell_height int, color_image bool, font *truetype.Font) (*image.RGBA, error) {
+	if cell_width < 1 || cell_height < 1 {
+		return nil, fmt.Errorf("%w: cell size %vx%v", ErrInvalidSize, cell_width, cell_height)
+	}
+
+	if len(arr) == 0 || len(arr[0]) == 0 {
+		return nil, transforms.ErrEmptyImage
+	}
+
 	pix_width := len(arr[0])
 	pix_height := len(arr)
 
 	// BOUNDS FOR KEEPING THE IMAGE QUALITY PERFECT:
-	out_width := pix_width * px_size
-	out_height := pix_height * px_size
+	out_width := pix_width * cell_width
+	out_height := pix_height * cell_height
 
 	newimg := image.NewRGBA(image.Rect(0, 0, out_width, out_height))
 	draw.Draw(newimg, newimg.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
 
-	context := InitializeContext(newimg, float64(px_size))
+	if font == nil {
+		var err error
+		if font, err = DefaultFont(); err != nil {
+			return nil, err
+		}
+	}
 
-	buffer := transforms.InitializeBuffer(0, px_size, out_width, out_height, px_size, newimg)
+	buffer := transforms.InitializeBuffer(0, cell_height, out_width, out_height, cell_width, cell_height, newimg)
 
-	buffer.WriteArray(context, arr, color_image)
+	// rows are drawn in parallel unless transforms.SetWorkers(1)
+	if err := buffer.WriteArrayAtlas(GlyphAtlas(font, cell_height), arr, color_image, transforms.Workers() > 1); err != nil {
+		return nil, err
+	}
 
-	return newimg
+	return newimg, nil
 }
 
-func WriteToTXT(arr [][]transforms.Pixel) {
+func WriteToTXT(arr [][]transforms.Pixel) error {
 	// .txt output
+	file, err := os.Create("output.txt")
+
+	if err != nil {
+		return fmt.Errorf("couldn't create output file: %w", err)
+	}
+
+	defer file.Close()
+
+	if _, err := file.WriteString(ToText(arr)); err != nil {
+		return fmt.Errorf("couldn't write output file: %w", err)
+	}
+
+	return nil
+}
+
+// Returns the characters of the array as text, one row per line. Sample with SampleSizes(n, 0.5) so the text keeps the image's proportions in a terminal.
+func ToText(arr [][]transforms.Pixel) string {
 	var sb strings.Builder
 	for i := range len(arr) {
 		for j := range len(arr[i]) {
 			cur := arr[i][j]
 			sb.WriteRune(cur.Character)
-			sb.WriteRune(' ')
 		}
 		sb.WriteRune('\n')
 	}
 
-	file, err := os.Create("output.txt")
-
-	if err != nil {
-		log.Fatal("Couldn't create output file " + err.Error())
-	}
-
-	defer file.Close()
-
-	file.WriteString(sb.String())
+	return sb.String()
 }
 
 // *****************
 // IO OPERATIONS
 // *****************
 
-func OpenPNGImg(filename string) (image image.Image, bounding image.Rectangle) {
+// Decodes an image of any registered format from r, sniffing the format from its first bytes.
+func OpenImg(r io.Reader) (image.Image, error) {
+	m, _, err := image.Decode(r)
+
+	if errors.Is(err, image.ErrFormat) {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedFormat, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDecode, err)
+	}
+
+	return m, nil
+}
+
+func OpenPNGImg(filename string) (image image.Image, bounding image.Rectangle, err error) {
 	img, err := os.Open(filename)
 
 	if err != nil {
-		log.Fatal("Error opening file: " + err.Error())
+		return nil, bounding, fmt.Errorf("error opening file: %w", err)
 	}
 
 	defer img.Close()
@@ -96,19 +230,19 @@ func OpenPNGImg(filename string) (image image.Image, bounding image.Rectangle) {
 	m, err := png.Decode(img)
 
 	if err != nil {
-		log.Fatal("Could not decode image: " + err.Error())
+		return nil, bounding, fmt.Errorf("%w: %q as png: %w", ErrDecode, filename, err)
 	}
 
 	bounds := m.Bounds()
 
-	return m, bounds
+	return m, bounds, nil
 }
 
-func OpenJPEGImg(filename string) (image image.Image, bounding image.Rectangle) {
+func OpenJPEGImg(filename string) (image image.Image, bounding image.Rectangle, err error) {
 	img, err := os.Open(filename)
 
 	if err != nil {
-		log.Fatal("Error opening file: " + err.Error())
+		return nil, bounding, fmt.Errorf("error opening file: %w", err)
 	}
 
 	defer img.Close()
@@ -116,12 +250,12 @@ func OpenJPEGImg(filename string) (image image.Image, bounding image.Rectangle)
 	m, err := jpeg.Decode(img)
 
 	if err != nil {
-		log.Fatal("Could not decode image: " + err.Error())
+		return nil, bounding, fmt.Errorf("%w: %q as jpeg: %w", ErrDecode, filename, err)
 	}
 
 	bounds := m.Bounds()
 
-	return m, bounds
+	return m, bounds, nil
 }
 
 func CreatePNG(filename string, newimg image.Image) (output string, err error) {
@@ -164,19 +298,26 @@ func CreateJPEG(filename string, newimg image.Image, quality int) (output string
 	return name, nil
 }
 
-func InitializeContext(newimg draw.Image, px_size float64) (cont *freetype.Context) {
-	fontBytes, err := os.ReadFile("Fonts/MC.ttf")
+func InitializeContext(newimg draw.Image, px_size float64) (cont *freetype.Context, err error) {
+	f, err := DefaultFont()
 	if err != nil {
-		log.Println(err)
-		return
+		return nil, err
 	}
 
-	f, err := freetype.ParseFont(fontBytes)
-	if err != nil {
-		log.Println(err)
-		return
+	return InitializeContextWithFont(newimg, px_size, f), nil
+}
+
+// Width over height of a cell fitting font's characters drawn px_size tall (its advance, fonts are assumed to be monospaced)
+func GlyphAspect(font *truetype.Font, px_size int) float64 {
+	advance := font.HMetric(fixed.Int26_6(px_size<<6), font.Index('M')).AdvanceWidth
+	if advance <= 0 {
+		return 1
 	}
 
+	return float64(advance) / 64 / float64(px_size)
+}
+
+func InitializeContextWithFont(newimg draw.Image, px_size float64, f *truetype.Font) (cont *freetype.Context) {
 	c := freetype.NewContext()
 
 	c.SetDPI(72)
@@ -189,72 +330,54 @@ func InitializeContext(newimg draw.Image, px_size float64) (cont *freetype.Conte
 	return c
 }
 
-func InitializeArray(img image.Image, sample_size int, pix_height int, pix_width int) (pixels [][]transforms.Pixel) {
+func InitializeArray(img image.Image, sample_x int, sample_y int, pix_height int, pix_width int) (pixels [][]transforms.Pixel) {
+	return InitializeArrayStride(img, float64(sample_x), float64(sample_y), pix_height, pix_width)
+}
+
+// Same as InitializeArray with fractional sample sizes, pixel (bx, by) averages the source pixels from bx*stride_x to (bx+1)*stride_x (rounded down, clipped to the image) and likewise for y
+func InitializeArrayStride(img image.Image, stride_x float64, stride_y float64, pix_height int, pix_width int) (pixels [][]transforms.Pixel) {
 	arr := make([][]transforms.Pixel, pix_height)
 	for y := range pix_height {
 		arr[y] = make([]transforms.Pixel, pix_width)
 	}
 
 	bounds := img.Bounds()
-	// consolidate a pixel grid of size sample_size x sample_size into one pixel
-	for by := range pix_height {
-		for bx := range pix_width {
-			x := bounds.Min.X + bx*sample_size
-			y := bounds.Min.Y + by*sample_size
-			red := uint32(0)
-			green := uint32(0)
-			blue := uint32(0)
-			alpha := uint32(0)
-			sample_count := 0
-			for offset_x := range sample_size {
-				if x+offset_x >= bounds.Max.X {
-					break
+	sum := newBlockSummer(img)
+
+	// clipped source columns of every block, the same for each row
+	xs := make([][2]int, pix_width)
+	for bx := range pix_width {
+		x, sample_x := transforms.StrideSpan(bx, stride_x)
+		xs[bx] = [2]int{bounds.Min.X + x, min(bounds.Min.X+x+sample_x, bounds.Max.X)}
+	}
+
+	// consolidate a pixel grid of size stride_x x stride_y into one pixel, rows in parallel
+	transforms.ParallelRows(pix_height, func(start int, end int) {
+		for by := start; by < end; by++ {
+			y, sample_y := transforms.StrideSpan(by, stride_y)
+			y0 := bounds.Min.Y + y
+			y1 := min(y0+sample_y, bounds.Max.Y)
+			for bx, span := range xs {
+				if span[1] <= span[0] || y1 <= y0 {
+					continue
 				}
-				for offset_y := 0; offset_y < sample_size; offset_y++ {
-					if y+offset_y >= bounds.Max.Y {
-						break
+				red, green, blue, alpha := sum(span[0], y0, span[1], y1)
+				sample_count := uint32((span[1] - span[0]) * (y1 - y0))
+				arr[by][bx] =
+					transforms.Pixel{
+						R: uint8(red / sample_count),
+						G: uint8(green / sample_count),
+						B: uint8(blue / sample_count),
+						A: uint8(alpha / sample_count),
 					}
-					r, g, b, a := img.At(x+offset_x, y+offset_y).RGBA()
-					red += r >> 8
-					green += g >> 8
-					blue += b >> 8
-					alpha += a >> 8
-					sample_count++
-				}
 			}
-			// fmt.Printf("Pixel: (%v, %v, %v, %v)\n", (red), (green), (blue), alpha)
-			red /= uint32(sample_count)
-			green /= uint32(sample_count)
-			blue /= uint32(sample_count)
-			alpha /= uint32(sample_count)
-			// fmt.Printf("Pixel: (%v, %v, %v, %v)\n", uint8(red), uint8(green), uint8(blue), alpha)
-			arr[by][bx] =
-				transforms.Pixel{
-					R: uint8(red),
-					G: uint8(green),
-					B: uint8(blue),
-					A: uint8(alpha),
-				}
 		}
-	}
+	})
 
 	return arr
 }
 
-func GetRunes(arr [][]transforms.Pixel) {
+func GetRunes(arr [][]transforms.Pixel, ramp transforms.Ramp) {
 	// luminescence to ascii mapping
-	mapping := map[int]rune{
-		0: ' ',
-		1: '.',
-		2: ':',
-		3: 'c',
-		4: 'o',
-		5: 'C',
-		6: 'O',
-		7: '0',
-		8: '@',
-		9: '■',
-	}
-
-	transforms.LuminFilter(arr, mapping)
+	transforms.LuminFilter(arr, ramp)
 }
@@ -0,0 +1,104 @@
+package ascii_img
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+// solid white cells, drawn without a glyph so every pixel of every cell is set
+func solidArray(columns int, rows int) [][]transforms.Pixel {
+	arr := make([][]transforms.Pixel, rows)
+	for y := range arr {
+		arr[y] = make([]transforms.Pixel, columns)
+		for x := range arr[y] {
+			arr[y][x] = transforms.Pixel{R: 255, G: 255, B: 255, A: 255}
+		}
+	}
+
+	return arr
+}
+
+func checkFilled(t *testing.T, img *image.RGBA) {
+	t.Helper()
+
+	bounds := img.Bounds()
+	for _, y := range []int{bounds.Min.Y, bounds.Max.Y - 1} {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if c := img.RGBAAt(x, y); c != (color.RGBA{255, 255, 255, 255}) {
+				t.Fatalf("pixel (%v, %v) is %v, want white", x, y, c)
+			}
+		}
+	}
+}
+
+func TestOutputImageWritesLastRow(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		transforms.SetWorkers(workers)
+
+		img, err := OutputImageCells(solidArray(5, 7), 4, 8, true, nil)
+		if err != nil {
+			t.Fatalf("workers %v: %v", workers, err)
+		}
+		if got := img.Bounds().Dy(); got != 7*8 {
+			t.Fatalf("image is %v tall, want %v", got, 7*8)
+		}
+		checkFilled(t, img)
+	}
+	transforms.SetWorkers(0)
+}
+
+func TestWriteArrayWritesLastRow(t *testing.T) {
+	arr := solidArray(5, 7)
+	img := image.NewRGBA(image.Rect(0, 0, 5*8, 7*8))
+
+	context, err := InitializeContext(img, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buffer := transforms.InitializeBuffer(0, 8, img.Bounds().Dx(), img.Bounds().Dy(), 8, 8, img)
+	if err := buffer.WriteArray(context, arr, true); err != nil {
+		t.Fatal(err)
+	}
+	checkFilled(t, img)
+
+	// one row more than fits
+	if err := buffer.WriteArray(context, solidArray(5, 1), true); err == nil {
+		t.Fatal("writing past the last row didn't fail")
+	}
+}
+
+func TestOpenImgErrors(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	truncated := encoded.Bytes()[:encoded.Len()/2]
+
+	if _, err := OpenImg(bytes.NewReader(truncated)); !errors.Is(err, ErrDecode) || errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("truncated png: got %v, want ErrDecode", err)
+	}
+
+	if _, err := OpenImg(bytes.NewReader([]byte("definitely not an image"))); !errors.Is(err, ErrUnsupportedFormat) || errors.Is(err, ErrDecode) {
+		t.Errorf("unknown format: got %v, want ErrUnsupportedFormat", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "broken.png")
+	if err := os.WriteFile(path, truncated, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := OpenPNGImg(path); !errors.Is(err, ErrDecode) {
+		t.Errorf("OpenPNGImg of a truncated png: got %v, want ErrDecode", err)
+	}
+	if _, _, err := OpenJPEGImg(path); !errors.Is(err, ErrDecode) {
+		t.Errorf("OpenJPEGImg of a png: got %v, want ErrDecode", err)
+	}
+}
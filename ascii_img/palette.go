@@ -0,0 +1,94 @@
+package ascii_img
+
+import "math"
+
+// *****************
+// TERMINAL PALETTES
+// *****************
+
+// a color in the OKLab space, where euclidean distance roughly matches perceived difference
+type oklab [3]float64
+
+func srgbToLinear(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// Reference - https://bottosson.github.io/posts/oklab/
+func toOKLab(c [3]uint8) oklab {
+	r, g, b := srgbToLinear(c[0]), srgbToLinear(c[1]), srgbToLinear(c[2])
+
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return oklab{
+		0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+func (lab oklab) distance(other oklab) float64 {
+	dl, da, db := lab[0]-other[0], lab[1]-other[1], lab[2]-other[2]
+	return dl*dl + da*da + db*db
+}
+
+type palette struct {
+	rgb   [][3]uint8
+	lab   []oklab
+	first int // xterm index of rgb[0]
+}
+
+func newPalette(rgb [][3]uint8, first int) *palette {
+	p := &palette{rgb: rgb, lab: make([]oklab, len(rgb)), first: first}
+	for i, c := range rgb {
+		p.lab[i] = toOKLab(c)
+	}
+
+	return p
+}
+
+// index into rgb of the entry perceptually closest to lab
+func (p *palette) nearest(lab oklab) int {
+	best := 0
+	best_dist := math.Inf(1)
+	for i := range p.lab {
+		if dist := lab.distance(p.lab[i]); dist < best_dist {
+			best, best_dist = i, dist
+		}
+	}
+
+	return best
+}
+
+// the 16 basic colors with xterm's default values, in SGR order (black, red, green, yellow, blue, magenta, cyan, white, then the bright ones)
+var xterm16 = newPalette([][3]uint8{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}, 0)
+
+// xterm colors 16-255 (6x6x6 cube + 24 grays). 0-15 are left out since terminal themes change them.
+var xterm256 = func() *palette {
+	levels := [6]uint8{0, 95, 135, 175, 215, 255}
+
+	rgb := make([][3]uint8, 0, 240)
+	for r := range 6 {
+		for g := range 6 {
+			for b := range 6 {
+				rgb = append(rgb, [3]uint8{levels[r], levels[g], levels[b]})
+			}
+		}
+	}
+
+	for i := range 24 {
+		gray := uint8(8 + 10*i)
+		rgb = append(rgb, [3]uint8{gray, gray, gray})
+	}
+
+	return newPalette(rgb, 16)
+}()
@@ -0,0 +1,478 @@
+package ascii_img
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"hash/fnv"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+)
+
+// *****************
+// PDF OUTPUT
+// *****************
+
+// Page size in points (1/72 inch)
+type PageSize struct {
+	Width, Height float64
+}
+
+var (
+	PageA4      = PageSize{595.28, 841.89}
+	PageA3      = PageSize{841.89, 1190.55}
+	PageA2      = PageSize{1190.55, 1683.78}
+	PageLetter  = PageSize{612, 792}
+	PageTabloid = PageSize{792, 1224}
+)
+
+var named_pages = map[string]PageSize{
+	"a4":      PageA4,
+	"a3":      PageA3,
+	"a2":      PageA2,
+	"letter":  PageLetter,
+	"tabloid": PageTabloid,
+}
+
+// Parses a page name (a4, a3, a2, letter, tabloid, add "-landscape" to turn it) or a size in points ("WxH", ex: "612x792")
+func ParsePageSize(s string) (PageSize, error) {
+	s = strings.ToLower(s)
+
+	name, landscape := strings.CutSuffix(s, "-landscape")
+	if page, ok := named_pages[name]; ok {
+		if landscape {
+			page.Width, page.Height = page.Height, page.Width
+		}
+		return page, nil
+	}
+
+	var page PageSize
+	if _, err := fmt.Sscanf(s, "%gx%g", &page.Width, &page.Height); err != nil || page.Width <= 0 || page.Height <= 0 {
+		return page, fmt.Errorf("unknown page size %q, use a4, a3, a2, letter, tabloid (-landscape) or WxH in points", s)
+	}
+
+	return page, nil
+}
+
+type PDFOptions struct {
+	// default PageA4
+	Page PageSize
+	// pixels each character takes up, like OutputImage's px_size (default 8). Characters are CellSize tall.
+	CellSize int
+	// width of every cell when it isn't square, like OutputImageCells' cell_width (0 is CellSize). Match the aspect the grid was sampled with.
+	CellWidth int
+	// printed resolution of a cell's pixels, a cell is CellSize / DPI inches wide (default 300)
+	DPI float64
+	// white space around the printed area of every page, in points (default 36, half an inch, negative for none)
+	Margin float64
+	// color characters with their pixel, otherwise they're white
+	Color bool
+	// color behind the characters (default black)
+	Background color.Color
+	// bundled font name or TrueType path to embed, DefaultFontName if empty. Only the glyphs used are embedded.
+	Font string
+	// draw crop marks at the corners of every page's printed area, handy when a poster is tiled across pages
+	CropMarks bool
+}
+
+/*
+Writes the array as a print ready pdf. Every cell is CellWidth x CellSize pixels at DPI, when the grid is larger than a page's printable area it is
+tiled across as many pages as needed, left to right then top to bottom. The font is embedded as a subset.
+*/
+func WritePDF(w io.Writer, arr [][]transforms.Pixel, opts PDFOptions) error {
+	if opts.Page.Width <= 0 || opts.Page.Height <= 0 {
+		opts.Page = PageA4
+	}
+	if opts.CellSize <= 0 {
+		opts.CellSize = 8
+	}
+	if opts.CellWidth <= 0 {
+		opts.CellWidth = opts.CellSize
+	}
+	if opts.DPI <= 0 {
+		opts.DPI = 300
+	}
+	if opts.Margin < 0 {
+		opts.Margin = 0
+	} else if opts.Margin == 0 {
+		opts.Margin = 36
+	}
+	if opts.Background == nil {
+		opts.Background = color.Black
+	}
+	if opts.Font == "" {
+		opts.Font = DefaultFontName
+	}
+
+	rows := len(arr)
+	if rows == 0 || len(arr[0]) == 0 {
+		return transforms.ErrEmptyImage
+	}
+	cols := len(arr[0])
+
+	cell := pdfCell{float64(opts.CellWidth) * 72 / opts.DPI, float64(opts.CellSize) * 72 / opts.DPI}
+	area_w := opts.Page.Width - 2*opts.Margin
+	area_h := opts.Page.Height - 2*opts.Margin
+	cols_per_page := int(area_w / cell.width)
+	rows_per_page := int(area_h / cell.height)
+	if cols_per_page < 1 || rows_per_page < 1 {
+		return fmt.Errorf("%w: a %.2fx%.2fpt cell doesn't fit in the page's %.2fx%.2fpt printable area", ErrInvalidSize, cell.width, cell.height, area_w, area_h)
+	}
+
+	font_bytes, err := FontBytes(opts.Font)
+	if err != nil {
+		return err
+	}
+	font, err := ParseFont(font_bytes)
+	if err != nil {
+		return err
+	}
+
+	// glyph ids of every character used
+	glyph_ids := make(map[rune]uint16)
+	used := make(map[uint16]rune)
+	for i := range rows {
+		for j := range len(arr[i]) {
+			r := arr[i][j].Character
+			if r == 0 || r == ' ' {
+				continue
+			}
+			if _, ok := glyph_ids[r]; !ok {
+				id := uint16(font.Index(r))
+				glyph_ids[r] = id
+				if _, ok := used[id]; !ok {
+					used[id] = r
+				}
+			}
+		}
+	}
+
+	pdf := &pdfWriter{}
+	pdf.reserve(2) // catalog, pages
+
+	font_ref, err := pdf.writeFont(font, font_bytes, used)
+	if err != nil {
+		return err
+	}
+
+	r, g, b, _ := opts.Background.RGBA()
+	background := [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}
+
+	var pages []int
+	for top := 0; top < rows; top += rows_per_page {
+		for left := 0; left < cols; left += cols_per_page {
+			tile := pdfTile{
+				left: left, top: top,
+				cols: min(cols_per_page, cols-left), rows: min(rows_per_page, rows-top),
+			}
+
+			content := pdfPageContent(arr, tile, cell, opts, background, glyph_ids, font)
+			content_ref := pdf.stream("", content)
+
+			page_ref := pdf.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+				pdfNum(opts.Page.Width), pdfNum(opts.Page.Height), font_ref, content_ref))
+			pages = append(pages, page_ref)
+		}
+	}
+
+	kids := make([]string, len(pages))
+	for i, page := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", page)
+	}
+	pdf.set(1, "<< /Type /Catalog /Pages 2 0 R >>")
+	pdf.set(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+
+	_, err = w.Write(pdf.bytes())
+	return err
+}
+
+// size of a cell in points, characters are drawn height tall
+type pdfCell struct {
+	width, height float64
+}
+
+// part of the grid printed on one page
+type pdfTile struct {
+	left, top  int
+	cols, rows int
+}
+
+func pdfPageContent(arr [][]transforms.Pixel, tile pdfTile, cell pdfCell, opts PDFOptions, background [3]uint8,
+	glyph_ids map[rune]uint16, font *truetype.Font) []byte {
+	var sb strings.Builder
+
+	// printed area, centered on the page
+	width, height := float64(tile.cols)*cell.width, float64(tile.rows)*cell.height
+	x0 := (opts.Page.Width - width) / 2
+	y0 := (opts.Page.Height + height) / 2 // top edge, pdf's y goes up
+
+	fmt.Fprintf(&sb, "%s rg %s %s %s %s re f\n", pdfColor(background), pdfNum(x0), pdfNum(y0-height), pdfNum(width), pdfNum(height))
+
+	if opts.CropMarks {
+		pdfCropMarks(&sb, x0, y0-height, width, height, opts.Margin)
+	}
+
+	// solid cells (rune 0, ex: XDoG)
+	for i := range tile.rows {
+		for j := range tile.cols {
+			cur := &arr[tile.top+i][tile.left+j]
+			if cur.Character != 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "%s rg %s %s %s %s re f\n", pdfColor([3]uint8{cur.R, cur.G, cur.B}),
+				pdfNum(x0+float64(j)*cell.width), pdfNum(y0-float64(i+1)*cell.height), pdfNum(cell.width), pdfNum(cell.height))
+		}
+	}
+
+	// every glyph is pulled back onto the cell grid with a TJ adjustment, in thousandths of the font size (the cell's height)
+	units := fixed.Int26_6(font.FUnitsPerEm())
+	step := cell.width * 1000 / cell.height
+	adjust := func(id uint16) int {
+		advance := font.HMetric(units, truetype.Index(id)).AdvanceWidth
+		return int(math.Round(float64(advance)*1000/float64(units) - step))
+	}
+
+	fmt.Fprintf(&sb, "BT /F1 %s Tf\n", pdfNum(cell.height))
+	last_color := [3]uint8{}
+	has_color := false
+	for i := range tile.rows {
+		baseline := y0 - float64(i+1)*cell.height
+		row := arr[tile.top+i][tile.left : tile.left+tile.cols]
+
+		for j := 0; j < len(row); {
+			if row[j].Character == 0 || row[j].Character == ' ' {
+				j++
+				continue
+			}
+
+			c := [3]uint8{255, 255, 255}
+			if opts.Color {
+				c = [3]uint8{row[j].R, row[j].G, row[j].B}
+			}
+			if !has_color || c != last_color {
+				fmt.Fprintf(&sb, "%s rg\n", pdfColor(c))
+				last_color, has_color = c, true
+			}
+
+			fmt.Fprintf(&sb, "1 0 0 1 %s %s Tm [", pdfNum(x0+float64(j)*cell.width), pdfNum(baseline))
+			for ; j < len(row); j++ {
+				cur := &row[j]
+				if cur.Character == 0 || cur.Character == ' ' {
+					break
+				}
+				if opts.Color && [3]uint8{cur.R, cur.G, cur.B} != c {
+					break
+				}
+
+				id := glyph_ids[cur.Character]
+				fmt.Fprintf(&sb, "<%04X>%d", id, adjust(id))
+			}
+			sb.WriteString("] TJ\n")
+		}
+	}
+	sb.WriteString("ET\n")
+
+	return []byte(sb.String())
+}
+
+// L shaped marks just outside each corner of the printed area
+func pdfCropMarks(sb *strings.Builder, x float64, y float64, width float64, height float64, margin float64) {
+	gap := min(3, margin/4)
+	length := min(18, margin-gap)
+	if length <= 0 {
+		return
+	}
+
+	sb.WriteString("q 0 0 0 RG 0.25 w\n")
+	for _, corner := range [][2]float64{{x, y}, {x + width, y}, {x, y + height}, {x + width, y + height}} {
+		// point away from the printed area
+		dx, dy := -1.0, -1.0
+		if corner[0] > x {
+			dx = 1
+		}
+		if corner[1] > y {
+			dy = 1
+		}
+
+		fmt.Fprintf(sb, "%s %s m %s %s l S\n", pdfNum(corner[0]+dx*gap), pdfNum(corner[1]), pdfNum(corner[0]+dx*(gap+length)), pdfNum(corner[1]))
+		fmt.Fprintf(sb, "%s %s m %s %s l S\n", pdfNum(corner[0]), pdfNum(corner[1]+dy*gap), pdfNum(corner[0]), pdfNum(corner[1]+dy*(gap+length)))
+	}
+	sb.WriteString("Q\n")
+}
+
+func pdfColor(c [3]uint8) string {
+	return fmt.Sprintf("%s %s %s", pdfNum(float64(c[0])/255), pdfNum(float64(c[1])/255), pdfNum(float64(c[2])/255))
+}
+
+func pdfNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// *****************
+// PDF OBJECTS
+// *****************
+
+type pdfWriter struct {
+	objects [][]byte // object i+1
+}
+
+// keeps n object numbers to fill in later with set
+func (pdf *pdfWriter) reserve(n int) {
+	for range n {
+		pdf.objects = append(pdf.objects, nil)
+	}
+}
+
+func (pdf *pdfWriter) set(ref int, body string) {
+	pdf.objects[ref-1] = []byte(body)
+}
+
+func (pdf *pdfWriter) object(body string) int {
+	pdf.objects = append(pdf.objects, []byte(body))
+	return len(pdf.objects)
+}
+
+// adds a flate compressed stream, extra goes into its dictionary
+func (pdf *pdfWriter) stream(extra string, data []byte) int {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "<< /Length %d /Filter /FlateDecode %s>>\nstream\n", compressed.Len(), extra)
+	body.Write(compressed.Bytes())
+	body.WriteString("\nendstream")
+
+	pdf.objects = append(pdf.objects, body.Bytes())
+	return len(pdf.objects)
+}
+
+func (pdf *pdfWriter) bytes() []byte {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(pdf.objects))
+	for i, body := range pdf.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(pdf.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pdf.objects)+1, xref)
+
+	return out.Bytes()
+}
+
+// embeds the used glyphs of font as a Type0 / CIDFontType2 font addressed by glyph id (Identity-H), returns the Type0 font's object
+func (pdf *pdfWriter) writeFont(font *truetype.Font, font_bytes []byte, used map[uint16]rune) (int, error) {
+	keep := make(map[uint16]bool, len(used))
+	ids := make([]int, 0, len(used))
+	for id := range used {
+		keep[id] = true
+		ids = append(ids, int(id))
+	}
+	sort.Ints(ids)
+
+	subset, err := subsetTTF(font_bytes, keep)
+	if err != nil {
+		return 0, err
+	}
+
+	// subset fonts are named with a tag made of 6 capital letters
+	hash := fnv.New32a()
+	for _, id := range ids {
+		hash.Write([]byte{byte(id >> 8), byte(id)})
+	}
+	sum := hash.Sum32()
+	tag := make([]byte, 6)
+	for i := range tag {
+		tag[i] = 'A' + byte(sum%26)
+		sum /= 26
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || strings.ContainsRune("()<>[]{}/%#", r) {
+			return -1
+		}
+		return r
+	}, font.Name(truetype.NameIDPostscriptName))
+	if name == "" {
+		name = "AsciiFont"
+	}
+	base_font := string(tag) + "+" + name
+
+	units := fixed.Int26_6(font.FUnitsPerEm())
+	scale := func(v fixed.Int26_6) int {
+		return int(math.Round(float64(v) * 1000 / float64(units)))
+	}
+
+	bounds := font.Bounds(units)
+	ascent, descent := max(0, scale(bounds.Max.Y)), min(0, scale(bounds.Min.Y))
+
+	file_ref := pdf.stream(fmt.Sprintf("/Length1 %d ", len(subset)), subset)
+
+	descriptor_ref := pdf.object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		base_font, scale(bounds.Min.X), scale(bounds.Min.Y), scale(bounds.Max.X), scale(bounds.Max.Y), ascent, descent, ascent, file_ref))
+
+	var widths strings.Builder
+	for _, id := range ids {
+		fmt.Fprintf(&widths, "%d [%d] ", id, scale(font.HMetric(units, truetype.Index(id)).AdvanceWidth))
+	}
+
+	cid_ref := pdf.object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		base_font, descriptor_ref, widths.String()))
+
+	to_unicode_ref := pdf.stream("", toUnicodeCMap(ids, used))
+
+	return pdf.object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		base_font, cid_ref, to_unicode_ref)), nil
+}
+
+// maps glyph ids back to characters so text can be copied out of the pdf
+func toUnicodeCMap(ids []int, used map[uint16]rune) []byte {
+	var sb strings.Builder
+	sb.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	sb.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	sb.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	for start := 0; start < len(ids); start += 100 {
+		block := ids[start:min(start+100, len(ids))]
+		fmt.Fprintf(&sb, "%d beginbfchar\n", len(block))
+		for _, id := range block {
+			sb.WriteString(fmt.Sprintf("<%04X> <", id))
+			for _, unit := range utf16Units(used[uint16(id)]) {
+				fmt.Fprintf(&sb, "%04X", unit)
+			}
+			sb.WriteString(">\n")
+		}
+		sb.WriteString("endbfchar\n")
+	}
+
+	sb.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+
+	return []byte(sb.String())
+}
+
+func utf16Units(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+
+	r -= 0x10000
+	return []uint16{uint16(0xD800 + (r >> 10)), uint16(0xDC00 + (r & 0x3FF))}
+}
@@ -0,0 +1,170 @@
+package ascii_img
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"testing"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+// objects of a pdf read through its xref table, checking every offset points at the object it claims to
+func readPDFObjects(t *testing.T, pdf []byte) map[int][]byte {
+	t.Helper()
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if startxref == nil {
+		t.Fatal("no startxref at the end of the file")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref %v doesn't point at the xref table", xref)
+	}
+
+	header := regexp.MustCompile(`^xref\n0 (\d+)\n0000000000 65535 f \n`).FindSubmatch(pdf[xref:])
+	if header == nil {
+		t.Fatal("malformed xref table header")
+	}
+	size, _ := strconv.Atoi(string(header[1]))
+
+	trailer := regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R >>`).FindSubmatch(pdf[xref:])
+	if trailer == nil || string(trailer[1]) != string(header[1]) {
+		t.Fatalf("trailer /Size doesn't match the xref table's %v entries", size)
+	}
+
+	entries := pdf[xref+len(header[0]):]
+	objects := make(map[int][]byte, size-1)
+	for ref := 1; ref < size; ref++ {
+		entry := entries[20*(ref-1) : 20*ref]
+		if !bytes.HasSuffix(entry, []byte(" 00000 n \n")) {
+			t.Fatalf("xref entry %v is malformed: %q", ref, entry)
+		}
+		offset, _ := strconv.Atoi(string(entry[:10]))
+
+		prefix := fmt.Sprintf("%d 0 obj\n", ref)
+		if !bytes.HasPrefix(pdf[offset:], []byte(prefix)) {
+			t.Fatalf("xref offset %v of object %v points at %q", offset, ref, pdf[offset:min(offset+16, len(pdf))])
+		}
+		body := pdf[offset+len(prefix):]
+		objects[ref] = body[:bytes.Index(body, []byte("\nendobj\n"))]
+	}
+
+	if count := bytes.Count(pdf[:xref], []byte("endobj\n")); count != size-1 {
+		t.Fatalf("file has %v objects, the xref table %v", count, size-1)
+	}
+
+	return objects
+}
+
+func inflatePDFStream(t *testing.T, object []byte) []byte {
+	t.Helper()
+
+	start := bytes.Index(object, []byte("stream\n"))
+	end := bytes.LastIndex(object, []byte("\nendstream"))
+	if start < 0 || end < 0 {
+		t.Fatalf("not a stream: %.40q", object)
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(object[start+len("stream\n") : end]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestWritePDF(t *testing.T) {
+	text := []string{"@#%.", "ab a", "...b"}
+	arr := make([][]transforms.Pixel, len(text))
+	for i, row := range text {
+		for _, r := range row {
+			arr[i] = append(arr[i], transforms.Pixel{R: 255, G: 255, B: 255, A: 255, Character: r})
+		}
+	}
+	arr[2][3].Character = 0 // a solid cell
+
+	var out bytes.Buffer
+	if err := WritePDF(&out, arr, PDFOptions{CellSize: 8, CellWidth: 4, DPI: 72}); err != nil {
+		t.Fatal(err)
+	}
+	objects := readPDFObjects(t, out.Bytes())
+
+	// catalog, pages, font file, descriptor, cid font, to unicode cmap, type0 font, then one content stream and page
+	if len(objects) != 9 {
+		t.Errorf("pdf has %v objects, want 9", len(objects))
+	}
+	if !bytes.Contains(objects[2], []byte("/Count 1")) {
+		t.Errorf("pages object is %q, want one page", objects[2])
+	}
+
+	font, err := DefaultFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content []byte
+	for _, object := range objects {
+		if bytes.Contains(object, []byte("/Type /Page ")) {
+			ref, _ := strconv.Atoi(string(regexp.MustCompile(`/Contents (\d+) 0 R`).FindSubmatch(object)[1]))
+			content = inflatePDFStream(t, objects[ref])
+		}
+	}
+	if content == nil {
+		t.Fatal("no page content stream")
+	}
+
+	// every drawn character is its glyph id, in order
+	var want, got []string
+	for i := range arr {
+		for _, cur := range arr[i] {
+			if cur.Character != 0 && cur.Character != ' ' {
+				want = append(want, fmt.Sprintf("%04X", font.Index(cur.Character)))
+			}
+		}
+	}
+	for _, match := range regexp.MustCompile(`<([0-9A-F]{4})>`).FindAllSubmatch(content, -1) {
+		got = append(got, string(match[1]))
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("content stream draws glyphs %v, want %v", got, want)
+	}
+
+	// text is a cell tall, solid cells are drawn a cell wide, rows are a cell apart
+	for _, want := range []string{"/F1 8 Tf", "4 8 re f"} {
+		if !bytes.Contains(content, []byte(want)) {
+			t.Errorf("content stream is missing %q:\n%s", want, content)
+		}
+	}
+	tms := regexp.MustCompile(`1 0 0 1 ([\d.]+) ([\d.]+) Tm`).FindAllSubmatch(content, -1)
+	if len(tms) < 3 {
+		t.Fatalf("content stream has %v text runs, want one per row at least:\n%s", len(tms), content)
+	}
+	y0, _ := strconv.ParseFloat(string(tms[0][2]), 64)
+	y1, _ := strconv.ParseFloat(string(tms[len(tms)-1][2]), 64)
+	if y0-y1 != 16 {
+		t.Errorf("first and last rows' baselines are %v apart, want 16", y0-y1)
+	}
+}
+
+func TestWritePDFTiles(t *testing.T) {
+	arr := solidArray(30, 20)
+
+	var out bytes.Buffer
+	// a 100pt square page holds 7 of the 10pt cells each way once the 15pt margins are taken off
+	if err := WritePDF(&out, arr, PDFOptions{Page: PageSize{100, 100}, CellSize: 10, DPI: 72, Margin: 15}); err != nil {
+		t.Fatal(err)
+	}
+	objects := readPDFObjects(t, out.Bytes())
+
+	if pages := 5 * 3; !bytes.Contains(objects[2], []byte(fmt.Sprintf("/Count %d", pages))) {
+		t.Errorf("pages object is %q, want %v pages", objects[2], pages)
+	}
+}
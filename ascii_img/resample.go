@@ -0,0 +1,202 @@
+package ascii_img
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"golang.org/x/image/draw"
+)
+
+// *****************
+// RESAMPLING
+// *****************
+
+// How source pixels are combined into a grid cell
+type Resampler int
+
+const (
+	ResampleBox        Resampler = iota // average of the whole pixels under the cell, like InitializeArray
+	ResampleArea                        // average weighted by how much of each pixel the cell covers
+	ResampleBilinear                    // tent filter, widened when downscaling
+	ResampleCatmullRom                  // cubic filter, sharper than bilinear
+	ResampleLanczos3                    // windowed sinc, sharpest, may ring around hard edges
+)
+
+var resampler_names = []string{"box", "area", "bilinear", "catmull-rom", "lanczos3"}
+
+// Parses one of box, area, bilinear, catmull-rom or lanczos3
+func ParseResampler(s string) (Resampler, error) {
+	for i, name := range resampler_names {
+		if strings.EqualFold(s, name) {
+			return Resampler(i), nil
+		}
+	}
+
+	return ResampleBox, fmt.Errorf("unknown resampler %q, use %s", s, strings.Join(resampler_names, ", "))
+}
+
+func (resampler Resampler) String() string {
+	if resampler < 0 || int(resampler) >= len(resampler_names) {
+		return fmt.Sprintf("Resampler(%d)", int(resampler))
+	}
+
+	return resampler_names[resampler]
+}
+
+var lanczos3 = &draw.Kernel{Support: 3, At: func(t float64) float64 {
+	if t == 0 {
+		return 1
+	}
+	if t >= 3 {
+		return 0
+	}
+	t *= math.Pi
+	return 3 * math.Sin(t) * math.Sin(t/3) / (t * t)
+}}
+
+/*
+Resamples the whole image onto a columns x rows grid, stretching it so every cell covers the same share of the image (unlike
+InitializeArray's clipped last row and column). The scale doesn't have to be a whole number, or even shrink the image.
+*/
+func ResampleArray(img image.Image, columns int, rows int, resampler Resampler) [][]transforms.Pixel {
+	bounds := img.Bounds()
+
+	switch resampler {
+	case ResampleArea:
+		return areaResample(img, columns, rows)
+	case ResampleBilinear, ResampleCatmullRom, ResampleLanczos3:
+		kernel := lanczos3
+		switch resampler {
+		case ResampleBilinear:
+			kernel = draw.BiLinear
+		case ResampleCatmullRom:
+			kernel = draw.CatmullRom
+		}
+
+		dst := image.NewRGBA(image.Rect(0, 0, columns, rows))
+		kernel.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+
+		return rgbaToArray(dst)
+	}
+
+	return InitializeArrayStride(img, float64(bounds.Dx())/float64(columns), float64(bounds.Dy())/float64(rows), rows, columns)
+}
+
+// Samples an already decoded image into a grid sized by size (see Size.Grid) with resampler, covering the whole image
+func InitializeResampled(img image.Image, size Size, cell_aspect float64, resampler Resampler) ([][]transforms.Pixel, error) {
+	if img == nil {
+		return nil, transforms.ErrEmptyImage
+	}
+
+	bounds := img.Bounds()
+	columns, rows, _, _, err := size.Grid(bounds.Dx(), bounds.Dy(), cell_aspect)
+	if err != nil {
+		return nil, err
+	}
+
+	return ResampleArray(img, columns, rows, resampler), nil
+}
+
+func rgbaToArray(img *image.RGBA) [][]transforms.Pixel {
+	bounds := img.Bounds()
+	arr := make([][]transforms.Pixel, bounds.Dy())
+	for y := range arr {
+		arr[y] = make([]transforms.Pixel, bounds.Dx())
+		row := img.Pix[y*img.Stride:]
+		for x := range arr[y] {
+			p := row[4*x : 4*x+4]
+			arr[y][x] = transforms.Pixel{R: p[0], G: p[1], B: p[2], A: p[3]}
+		}
+	}
+
+	return arr
+}
+
+// source pixel and how much of it lands in an output cell
+type areaWeight struct {
+	src    int
+	weight float64
+}
+
+// for each of the n output cells along a length long axis, the source pixels it covers weighted by coverage (summing to 1)
+func areaWeights(length int, n int) [][]areaWeight {
+	scale := float64(length) / float64(n)
+	weights := make([][]areaWeight, n)
+	for i := range n {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for src := int(start); src < length && float64(src) < end; src++ {
+			overlap := min(end, float64(src+1)) - max(start, float64(src))
+			if overlap > 0 {
+				weights[i] = append(weights[i], areaWeight{src, overlap / scale})
+			}
+		}
+	}
+
+	return weights
+}
+
+/*
+area average, separable: every source row under an output row is shrunk to columns wide then added on with its coverage. Output rows
+run in parallel. Pixels are read like InitializeArray's, through the fast paths of common image types.
+*/
+func areaResample(img image.Image, columns int, rows int) [][]transforms.Pixel {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	sum_block := newBlockSummer(img)
+
+	col_weights := areaWeights(width, columns)
+	row_weights := areaWeights(height, rows)
+
+	arr := make([][]transforms.Pixel, rows)
+	transforms.ParallelRows(rows, func(start int, end int) {
+		src_row := make([][4]float64, width)
+		shrunk := make([][4]float64, columns)
+		acc := make([][4]float64, columns)
+		loaded := -1
+
+		for by := start; by < end; by++ {
+			clear(acc)
+			for _, rw := range row_weights[by] {
+				// neighbouring output rows (and every output row when upscaling) share source rows
+				if rw.src != loaded {
+					y := bounds.Min.Y + rw.src
+					for x := range width {
+						r, g, b, a := sum_block(bounds.Min.X+x, y, bounds.Min.X+x+1, y+1)
+						src_row[x] = [4]float64{float64(r), float64(g), float64(b), float64(a)}
+					}
+					for bx, weights := range col_weights {
+						var sum [4]float64
+						for _, w := range weights {
+							for k := range sum {
+								sum[k] += src_row[w.src][k] * w.weight
+							}
+						}
+						shrunk[bx] = sum
+					}
+					loaded = rw.src
+				}
+
+				for bx := range acc {
+					for k := range acc[bx] {
+						acc[bx][k] += shrunk[bx][k] * rw.weight
+					}
+				}
+			}
+
+			arr[by] = make([]transforms.Pixel, columns)
+			for bx, sum := range acc {
+				arr[by][bx] = transforms.Pixel{R: to8(sum[0]), G: to8(sum[1]), B: to8(sum[2]), A: to8(sum[3])}
+			}
+		}
+	})
+
+	return arr
+}
+
+// weighted 8 bit channel back to a uint8, rounded
+func to8(v float64) uint8 {
+	return uint8(min(255, max(0, math.Round(v))))
+}
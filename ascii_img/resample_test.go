@@ -0,0 +1,56 @@
+package ascii_img
+
+import (
+	"image"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// hides the concrete type of an image so it is read through img.At
+type genericImage struct {
+	image.Image
+}
+
+func TestAreaResample(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 1))
+	img.Pix = []uint8{0, 90, 180}
+
+	arr := ResampleArray(img, 2, 1, ResampleArea)
+	// each cell covers 1.5 pixels: 0 and half of 90, half of 90 and 180
+	if got := [2]uint8{arr[0][0].R, arr[0][1].R}; got != [2]uint8{30, 150} {
+		t.Errorf("cells are %v, want [30 150]", got)
+	}
+}
+
+func TestAreaResampleFastPaths(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	rect := image.Rect(3, 5, 3+37, 5+23)
+
+	rgba := image.NewRGBA(rect)
+	rng.Read(rgba.Pix)
+	nrgba := image.NewNRGBA(rect)
+	rng.Read(nrgba.Pix)
+	gray := image.NewGray(rect)
+	rng.Read(gray.Pix)
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+	rng.Read(ycbcr.Y)
+	rng.Read(ycbcr.Cb)
+	rng.Read(ycbcr.Cr)
+
+	// premultiplied
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		c := rgba.Pix[i+3]
+		rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2] = min(rgba.Pix[i], c), min(rgba.Pix[i+1], c), min(rgba.Pix[i+2], c)
+	}
+
+	for _, img := range []image.Image{rgba, nrgba, gray, ycbcr} {
+		for _, size := range [][2]int{{5, 4}, {37, 23}, {50, 31}} {
+			got := ResampleArray(img, size[0], size[1], ResampleArea)
+			want := ResampleArray(genericImage{img}, size[0], size[1], ResampleArea)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%T at %vx%v differs from reading it through At", img, size[0], size[1])
+			}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package ascii_img
+
+import (
+	"image"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+// *****************
+// PIXEL SAMPLING
+// *****************
+
+/*
+Sums the 8 bit channels of the pixels in [x0, x1) x [y0, y1), alpha premultiplied like image.Image's RGBA (>> 8). Common image types
+read their Pix slices directly, which is several times faster than going through img.At and gives the exact same sums.
+*/
+type blockSummer func(x0 int, y0 int, x1 int, y1 int) (r uint32, g uint32, b uint32, a uint32)
+
+func newBlockSummer(img image.Image) blockSummer {
+	switch src := img.(type) {
+	case *image.YCbCr:
+		// chroma offsets split into a row and a column part, so subsampling is looked up once per column instead of per pixel
+		min_x := src.Rect.Min.X
+		chroma_x := make([]int, src.Rect.Dx())
+		for x := range chroma_x {
+			chroma_x[x] = src.COffset(min_x+x, src.Rect.Min.Y) - src.COffset(min_x, src.Rect.Min.Y)
+		}
+		return func(x0, y0, x1, y1 int) (r, g, b, a uint32) {
+			for y := y0; y < y1; y++ {
+				lum := src.Y[src.YOffset(x0, y):src.YOffset(x1, y)]
+				chroma_row := src.COffset(min_x, y)
+				for i, col := range chroma_x[x0-min_x : x1-min_x] {
+					c := chroma_row + col
+					pr, pg, pb := ycbcrToRGB(lum[i], src.Cb[c], src.Cr[c])
+					r += pr
+					g += pg
+					b += pb
+				}
+			}
+			return r, g, b, 0xff * uint32((x1-x0)*(y1-y0))
+		}
+	case *image.RGBA:
+		return func(x0, y0, x1, y1 int) (r, g, b, a uint32) {
+			for y := y0; y < y1; y++ {
+				row := src.Pix[src.PixOffset(x0, y):src.PixOffset(x1, y)]
+				for i := 0; i < len(row); i += 4 {
+					r += uint32(row[i])
+					g += uint32(row[i+1])
+					b += uint32(row[i+2])
+					a += uint32(row[i+3])
+				}
+			}
+			return r, g, b, a
+		}
+	case *image.NRGBA:
+		return func(x0, y0, x1, y1 int) (r, g, b, a uint32) {
+			for y := y0; y < y1; y++ {
+				row := src.Pix[src.PixOffset(x0, y):src.PixOffset(x1, y)]
+				for i := 0; i < len(row); i += 4 {
+					// same as color.NRGBA's RGBA
+					pa := uint32(row[i+3])
+					r += (uint32(row[i]) * 0x101 * pa / 0xff) >> 8
+					g += (uint32(row[i+1]) * 0x101 * pa / 0xff) >> 8
+					b += (uint32(row[i+2]) * 0x101 * pa / 0xff) >> 8
+					a += pa
+				}
+			}
+			return r, g, b, a
+		}
+	case *image.Gray:
+		return func(x0, y0, x1, y1 int) (r, g, b, a uint32) {
+			for y := y0; y < y1; y++ {
+				for _, v := range src.Pix[src.PixOffset(x0, y):src.PixOffset(x1, y)] {
+					r += uint32(v)
+				}
+			}
+			return r, r, r, 0xff * uint32((x1-x0)*(y1-y0))
+		}
+	}
+
+	return func(x0, y0, x1, y1 int) (r, g, b, a uint32) {
+		for y := y0; y < y1; y++ {
+			for x := x0; x < x1; x++ {
+				pr, pg, pb, pa := img.At(x, y).RGBA()
+				r += pr >> 8
+				g += pg >> 8
+				b += pb >> 8
+				a += pa >> 8
+			}
+		}
+		return r, g, b, a
+	}
+}
+
+// same as color.YCbCr's RGBA >> 8
+func ycbcrToRGB(y uint8, cb uint8, cr uint8) (r uint32, g uint32, b uint32) {
+	yy1 := int32(y) * 0x10101
+	cb1 := int32(cb) - 128
+	cr1 := int32(cr) - 128
+
+	r1 := yy1 + 91881*cr1
+	if uint32(r1)&0xff000000 == 0 {
+		r1 >>= 8
+	} else {
+		r1 = ^(r1 >> 31) & 0xffff
+	}
+
+	g1 := yy1 - 22554*cb1 - 46802*cr1
+	if uint32(g1)&0xff000000 == 0 {
+		g1 >>= 8
+	} else {
+		g1 = ^(g1 >> 31) & 0xffff
+	}
+
+	b1 := yy1 + 116130*cb1
+	if uint32(b1)&0xff000000 == 0 {
+		b1 >>= 8
+	} else {
+		b1 = ^(b1 >> 31) & 0xffff
+	}
+
+	return uint32(r1) >> 8, uint32(g1) >> 8, uint32(b1) >> 8
+}
+
+// Copies img at full resolution into a transforms.Plane, for filters that look at more than the sampled grid
+func PlaneFromImage(img image.Image) *transforms.Plane {
+	bounds := img.Bounds()
+	plane := transforms.NewPlane(bounds.Dx(), bounds.Dy())
+	sum := newBlockSummer(img)
+
+	transforms.ParallelRows(plane.Height, func(start int, end int) {
+		for y := start; y < end; y++ {
+			for x := range plane.Width {
+				r, g, b, a := sum(bounds.Min.X+x, bounds.Min.Y+y, bounds.Min.X+x+1, bounds.Min.Y+y+1)
+				p := transforms.Pixel{R: uint8(r), G: uint8(g), B: uint8(b), A: uint8(a)}
+				plane.SetPixel(x, y, &p)
+			}
+		}
+	})
+
+	return plane
+}
@@ -0,0 +1,81 @@
+package ascii_img
+
+import (
+	"image"
+	"image/draw"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// a ycbcr image like a decoded jpeg, and the same pixels in every other type with a fast path
+func sampleImages(width int, height int) map[string]image.Image {
+	rng := rand.New(rand.NewSource(1))
+	rect := image.Rect(0, 0, width, height)
+
+	ycbcr := image.NewYCbCr(rect, image.YCbCrSubsampleRatio420)
+	rng.Read(ycbcr.Y)
+	rng.Read(ycbcr.Cb)
+	rng.Read(ycbcr.Cr)
+
+	rgba := image.NewRGBA(rect)
+	draw.Draw(rgba, rect, ycbcr, image.Point{}, draw.Src)
+	nrgba := image.NewNRGBA(rect)
+	draw.Draw(nrgba, rect, ycbcr, image.Point{}, draw.Src)
+	// translucent pixels exercise NRGBA's premultiplication
+	for i := 3; i < len(nrgba.Pix); i += 4 {
+		nrgba.Pix[i] = uint8(rng.Intn(256))
+	}
+	gray := image.NewGray(rect)
+	draw.Draw(gray, rect, ycbcr, image.Point{}, draw.Src)
+
+	return map[string]image.Image{"YCbCr": ycbcr, "RGBA": rgba, "NRGBA": nrgba, "Gray": gray}
+}
+
+func TestInitializeArrayFastPaths(t *testing.T) {
+	for name, img := range sampleImages(67, 45) {
+		for _, stride := range [][2]float64{{1, 1}, {8, 8}, {3.5, 7}} {
+			bounds := img.Bounds()
+			columns, rows := cellCount(float64(bounds.Dx()), stride[0]), cellCount(float64(bounds.Dy()), stride[1])
+
+			fast := InitializeArrayStride(img, stride[0], stride[1], rows, columns)
+			// wrapping the image hides its type, so only the generic path can read it
+			generic := InitializeArrayStride(genericImage{img}, stride[0], stride[1], rows, columns)
+
+			if !reflect.DeepEqual(fast, generic) {
+				t.Errorf("%s at a %vx%v stride differs from the generic path", name, stride[0], stride[1])
+			}
+		}
+	}
+}
+
+func benchmarkInitializeArray(b *testing.B, img image.Image) {
+	const sample_size = 8
+	bounds := img.Bounds()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		InitializeArray(img, sample_size, sample_size, bounds.Dy()/sample_size, bounds.Dx()/sample_size)
+	}
+}
+
+func BenchmarkInitializeArrayYCbCr(b *testing.B) {
+	benchmarkInitializeArray(b, sampleImages(1920, 1080)["YCbCr"])
+}
+
+func BenchmarkInitializeArrayRGBA(b *testing.B) {
+	benchmarkInitializeArray(b, sampleImages(1920, 1080)["RGBA"])
+}
+
+func BenchmarkInitializeArrayNRGBA(b *testing.B) {
+	benchmarkInitializeArray(b, sampleImages(1920, 1080)["NRGBA"])
+}
+
+func BenchmarkInitializeArrayGray(b *testing.B) {
+	benchmarkInitializeArray(b, sampleImages(1920, 1080)["Gray"])
+}
+
+// the img.At path every other image type takes
+func BenchmarkInitializeArrayGeneric(b *testing.B) {
+	benchmarkInitializeArray(b, genericImage{sampleImages(1920, 1080)["YCbCr"]})
+}
@@ -0,0 +1,212 @@
+package ascii_img
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sync"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"github.com/golang/freetype/truetype"
+)
+
+// *****************
+// SHAPE MATCHING
+// *****************
+
+// glyphs are measured this many pixels tall, plenty to tell their shapes apart
+const shape_measure_size = 48
+
+/*
+A ShapeMatcher picks characters by shape instead of average brightness. Cells and glyphs are both split into a grid_x x grid_y grid of
+regions; a glyph's sampling vector is how much of each region it covers, a cell's is the lightness of each region. A cell gets the glyph
+with the nearest vector, so a cell dark on top and light at the bottom gets a glyph heavy at the bottom. Safe for concurrent use.
+*/
+type ShapeMatcher struct {
+	grid_x, grid_y int
+	runes          []rune
+	vectors        [][]float64
+
+	// nearest glyph of every quantized vector seen so far
+	cache sync.Map
+}
+
+/*
+Measures the sampling vector of every character of charset in font, drawn the way OutputImage draws them (baseline at the bottom of a
+cell as wide as the font's advance). Characters the font doesn't have are skipped. Vectors are scaled so the densest region of any
+glyph is 1, the same range as a cell's lightness.
+*/
+func NewShapeMatcher(font *truetype.Font, charset transforms.Ramp, grid_x int, grid_y int) (*ShapeMatcher, error) {
+	if font == nil {
+		return nil, fmt.Errorf("%w: no font to measure", ErrFontLoad)
+	}
+
+	if grid_x < 1 || grid_y < 1 || grid_x*grid_y > 12 {
+		return nil, fmt.Errorf("%w: shape grid %vx%v, needs 1 to 12 regions", ErrInvalidSize, grid_x, grid_y)
+	}
+
+	height := shape_measure_size
+	width := max(1, int(math.Round(GlyphAspect(font, height)*float64(height))))
+	atlas := transforms.NewGlyphAtlas(font, float64(height), nil)
+
+	matcher := &ShapeMatcher{grid_x: grid_x, grid_y: grid_y}
+	densest := 0.0
+	for _, r := range charset {
+		if r != ' ' && font.Index(r) == 0 {
+			continue
+		}
+
+		// cell from (0, -height) to (width, 0), the baseline origin being its bottom left corner
+		mask := atlas.Mask(r)
+		vector := make([]float64, grid_x*grid_y)
+		for gy := range grid_y {
+			y0, count_y := transforms.StrideSpan(gy, float64(height)/float64(grid_y))
+			for gx := range grid_x {
+				x0, count_x := transforms.StrideSpan(gx, float64(width)/float64(grid_x))
+				region := image.Rect(x0, y0-height, x0+count_x, y0-height+count_y)
+
+				total := 0
+				inked := region.Intersect(mask.Bounds())
+				for y := inked.Min.Y; y < inked.Max.Y; y++ {
+					for x := inked.Min.X; x < inked.Max.X; x++ {
+						total += int(mask.AlphaAt(x, y).A)
+					}
+				}
+
+				vector[gy*grid_x+gx] = float64(total) / float64(255*count_x*count_y)
+				densest = max(densest, vector[gy*grid_x+gx])
+			}
+		}
+
+		matcher.runes = append(matcher.runes, r)
+		matcher.vectors = append(matcher.vectors, vector)
+	}
+
+	if len(matcher.runes) == 0 {
+		return nil, fmt.Errorf("%w: font has none of the characters %q", transforms.ErrInvalidRamp, string(charset))
+	}
+
+	if densest > 0 {
+		for _, vector := range matcher.vectors {
+			for k := range vector {
+				vector[k] /= densest
+			}
+		}
+	}
+
+	return matcher, nil
+}
+
+// Regions of the cell along each axis
+func (matcher *ShapeMatcher) Grid() (grid_x int, grid_y int) {
+	return matcher.grid_x, matcher.grid_y
+}
+
+// The glyph whose sampling vector is nearest to sample (lightness 0-1 of every region, row by row)
+func (matcher *ShapeMatcher) Match(sample []float64) rune {
+	// 5 bits per region, close enough that neighbouring cells share entries
+	key := uint64(0)
+	for _, v := range sample {
+		key = key<<5 | uint64(min(31, max(0, math.Round(v*31))))
+	}
+
+	if r, ok := matcher.cache.Load(key); ok {
+		return r.(rune)
+	}
+
+	best, best_dist := 0, math.Inf(1)
+	for i, vector := range matcher.vectors {
+		dist := 0.0
+		for k, v := range vector {
+			d := v - sample[k]
+			dist += d * d
+		}
+		if dist < best_dist {
+			best, best_dist = i, dist
+		}
+	}
+
+	matcher.cache.Store(key, matcher.runes[best])
+
+	return matcher.runes[best]
+}
+
+/*
+Sets the character of every cell of arr to the glyph matching its shape, sampled from src's full resolution pixels. Cells too small to
+split reuse pixels between regions.
+*/
+func (matcher *ShapeMatcher) Apply(src Source, arr [][]transforms.Pixel) {
+	bounds := src.Image.Bounds()
+	sum := newBlockSummer(src.Image)
+
+	transforms.ParallelRows(len(arr), func(start int, end int) {
+		sample := make([]float64, matcher.grid_x*matcher.grid_y)
+
+		for by := start; by < end; by++ {
+			cell_y, cell_h := transforms.StrideSpan(by, src.StrideY)
+			for bx := range len(arr[by]) {
+				cell_x, cell_w := transforms.StrideSpan(bx, src.StrideX)
+
+				for gy := range matcher.grid_y {
+					y0, count_y := transforms.StrideSpan(gy, float64(cell_h)/float64(matcher.grid_y))
+					y0 += bounds.Min.Y + cell_y
+					y1 := min(y0+count_y, bounds.Max.Y)
+					for gx := range matcher.grid_x {
+						x0, count_x := transforms.StrideSpan(gx, float64(cell_w)/float64(matcher.grid_x))
+						x0 += bounds.Min.X + cell_x
+						x1 := min(x0+count_x, bounds.Max.X)
+
+						lightness := 0.0
+						if x1 > x0 && y1 > y0 {
+							r, g, b, _ := sum(x0, y0, x1, y1)
+							n := uint32((x1 - x0) * (y1 - y0))
+							p := transforms.Pixel{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n)}
+							lightness = transforms.Luminance(&p) / 255
+						}
+						sample[gy*matcher.grid_x+gx] = lightness
+					}
+				}
+
+				arr[by][bx].Character = matcher.Match(sample)
+			}
+		}
+	})
+}
+
+/*
+Picks every character by shape (see ShapeMatcher) out of the ramp, measured in font (DefaultFont() if nil) with grid_x x grid_y regions
+per cell. Sharper contours than a luminance ramp, without an edge pass.
+*/
+func ShapeFilter(font *truetype.Font, grid_x int, grid_y int) SourceFilter {
+	var mu sync.Mutex
+	matchers := make(map[string]*ShapeMatcher)
+
+	return func(src Source, arr [][]transforms.Pixel, ramp transforms.Ramp) ([][]transforms.Pixel, error) {
+		if len(ramp) == 0 {
+			ramp = transforms.StandardRamp()
+		}
+
+		mu.Lock()
+		matcher, ok := matchers[string(ramp)]
+		if !ok {
+			f := font
+			var err error
+			if f == nil {
+				f, err = DefaultFont()
+			}
+			if err == nil {
+				matcher, err = NewShapeMatcher(f, ramp, grid_x, grid_y)
+			}
+			if err != nil {
+				mu.Unlock()
+				return nil, err
+			}
+			matchers[string(ramp)] = matcher
+		}
+		mu.Unlock()
+
+		matcher.Apply(src, arr)
+
+		return arr, nil
+	}
+}
@@ -0,0 +1,104 @@
+package ascii_img
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+func TestShapeMatcherContour(t *testing.T) {
+	// Mont sits on its baseline, the default font's glyphs are drawn high in the cell
+	font, err := LoadFont("Mont")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := NewShapeMatcher(font, PrintableASCII(), 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two 12x12 cells of the same average brightness: black over white, then a uniform mid-gray
+	img := image.NewGray(image.Rect(0, 0, 24, 12))
+	for y := range 12 {
+		for x := range 24 {
+			v := uint8(128)
+			if x < 12 {
+				v = 0
+				if y >= 6 {
+					v = 255
+				}
+			}
+			img.SetGray(x, y, color.Gray{v})
+		}
+	}
+
+	arr := [][]transforms.Pixel{make([]transforms.Pixel, 2)}
+	matcher.Apply(Source{img, 12, 12}, arr)
+
+	split, uniform := arr[0][0].Character, arr[0][1].Character
+	if split == uniform {
+		t.Fatalf("black over white and uniform gray cells both got %q", split)
+	}
+
+	// the split cell's glyph is inked in its bottom regions more than its top ones
+	vector := matcher.vectors[indexOf(matcher.runes, split)]
+	if top, bottom := vector[0]+vector[1], vector[4]+vector[5]; bottom <= top {
+		t.Errorf("black over white cell got %q, covering %.2f of its top and %.2f of its bottom", split, top/2, bottom/2)
+	}
+}
+
+func TestShapeMatcherCache(t *testing.T) {
+	font, err := DefaultFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := NewShapeMatcher(font, transforms.StandardRamp(), 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := func() int {
+		n := 0
+		matcher.cache.Range(func(_, _ any) bool {
+			n++
+			return true
+		})
+		return n
+	}
+
+	first := matcher.Match([]float64{0.2, 0.8})
+	if entries() != 1 {
+		t.Fatalf("cache has %v entries after one match, want 1", entries())
+	}
+
+	// close enough to quantize the same, answered from the same entry
+	if got := matcher.Match([]float64{0.201, 0.799}); got != first {
+		t.Errorf("nearby vector got %q, want the cached %q", got, first)
+	}
+	if entries() != 1 {
+		t.Errorf("cache has %v entries after a repeated vector, want 1", entries())
+	}
+
+	// a repeated vector is read from the cache, not searched again
+	matcher.cache.Range(func(key, _ any) bool {
+		matcher.cache.Store(key, '?')
+		return true
+	})
+	if got := matcher.Match([]float64{0.2, 0.8}); got != '?' {
+		t.Errorf("repeated vector got %q, want the cached '?'", got)
+	}
+}
+
+func indexOf(runes []rune, r rune) int {
+	for i, c := range runes {
+		if c == r {
+			return i
+		}
+	}
+
+	return -1
+}
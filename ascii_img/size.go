@@ -0,0 +1,151 @@
+package ascii_img
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// *****************
+// OUTPUT SIZING
+// *****************
+
+// How the size of the character grid is picked, see Size
+type SizeMode int
+
+const (
+	SizeSample   SizeMode = iota // every Sample wide block becomes a character
+	SizeColumns                  // Columns characters wide
+	SizeRows                     // Rows characters tall
+	SizeFit                      // as large as fits in Columns x Rows
+	SizeTerminal                 // as large as fits in the terminal, see TerminalSize
+)
+
+/*
+Size of the character grid. Whatever the mode, the grid keeps the image's proportions once drawn in cells of the given aspect (width
+over height), so the sampling stride is usually fractional: a 1000px wide image at 300 columns samples every 3.33 pixels. The grid
+covers the whole image, when the stride doesn't divide it the last row and column average the pixels left over.
+*/
+type Size struct {
+	Mode    SizeMode
+	Sample  float64
+	Columns int
+	Rows    int
+}
+
+// Averages every sample_size wide block into one character, the block height follows the cell aspect
+func BySample(sample_size float64) Size {
+	return Size{Mode: SizeSample, Sample: sample_size}
+}
+
+// Grid columns characters wide
+func ByColumns(columns int) Size {
+	return Size{Mode: SizeColumns, Columns: columns}
+}
+
+// Grid rows characters tall
+func ByRows(rows int) Size {
+	return Size{Mode: SizeRows, Rows: rows}
+}
+
+// Largest grid that fits in columns x rows characters
+func FitWithin(columns int, rows int) Size {
+	return Size{Mode: SizeFit, Columns: columns, Rows: rows}
+}
+
+// Largest grid that fits in the terminal, leaving a line for the prompt
+func FitTerminal() Size {
+	return Size{Mode: SizeTerminal}
+}
+
+/*
+Dimensions of the grid for a width x height image drawn in cells cell_aspect times as wide as they are tall (<= 0 means square cells),
+and the sampling stride in image pixels along each axis.
+*/
+func (size Size) Grid(width int, height int, cell_aspect float64) (columns int, rows int, stride_x float64, stride_y float64, err error) {
+	if width < 1 || height < 1 {
+		return 0, 0, 0, 0, fmt.Errorf("%w: %vx%v image", ErrInvalidSize, width, height)
+	}
+
+	if cell_aspect <= 0 {
+		cell_aspect = 1
+	}
+
+	w, h := float64(width), float64(height)
+
+	switch size.Mode {
+	case SizeSample:
+		if size.Sample <= 0 {
+			return 0, 0, 0, 0, fmt.Errorf("%w: sample size %v", ErrInvalidSize, size.Sample)
+		}
+		stride_x = size.Sample
+		stride_y = stride_x / cell_aspect
+		columns, rows = cellCount(w, stride_x), cellCount(h, stride_y)
+	case SizeColumns:
+		if size.Columns < 1 {
+			return 0, 0, 0, 0, fmt.Errorf("%w: %v columns", ErrInvalidSize, size.Columns)
+		}
+		columns = size.Columns
+		stride_x = w / float64(columns)
+		stride_y = stride_x / cell_aspect
+		rows = cellCount(h, stride_y)
+	case SizeRows:
+		if size.Rows < 1 {
+			return 0, 0, 0, 0, fmt.Errorf("%w: %v rows", ErrInvalidSize, size.Rows)
+		}
+		rows = size.Rows
+		stride_y = h / float64(rows)
+		stride_x = stride_y * cell_aspect
+		columns = cellCount(w, stride_x)
+	case SizeFit, SizeTerminal:
+		box_columns, box_rows := size.Columns, size.Rows
+		if size.Mode == SizeTerminal {
+			if box_columns, box_rows, err = TerminalSize(); err != nil {
+				return 0, 0, 0, 0, err
+			}
+			box_rows = max(1, box_rows-1)
+		}
+		if box_columns < 1 || box_rows < 1 {
+			return 0, 0, 0, 0, fmt.Errorf("%w: %vx%v box", ErrInvalidSize, box_columns, box_rows)
+		}
+		// the smallest stride that fits both ways
+		stride_x = max(w/float64(box_columns), h/float64(box_rows)*cell_aspect)
+		stride_y = stride_x / cell_aspect
+		columns = min(box_columns, cellCount(w, stride_x))
+		rows = min(box_rows, cellCount(h, stride_y))
+	default:
+		return 0, 0, 0, 0, fmt.Errorf("%w: unknown size mode %v", ErrInvalidSize, size.Mode)
+	}
+
+	if columns < 1 || rows < 1 || stride_x > w+1e-9 || stride_y > h+1e-9 {
+		return 0, 0, 0, 0, fmt.Errorf("%w: a %.4gx%.4g sample is larger than the %vx%v image", ErrInvalidSize, stride_x, stride_y, width, height)
+	}
+
+	return columns, rows, stride_x, stride_y, nil
+}
+
+// how many strides it takes to cover length, the last one is clipped to the image. Forgives floating point error.
+func cellCount(length float64, stride float64) int {
+	return int(math.Ceil(length/stride - 1e-9))
+}
+
+/*
+Columns and rows of the terminal attached to stdout, stderr or stdin (ioctl TIOCGWINSZ, linux only), falling back to the COLUMNS and
+LINES environment variables. Returns ErrNoTerminal if neither is available.
+*/
+func TerminalSize() (columns int, rows int, err error) {
+	for _, f := range []*os.File{os.Stdout, os.Stderr, os.Stdin} {
+		if columns, rows, err = terminalSize(f); err == nil && columns > 0 && rows > 0 {
+			return columns, rows, nil
+		}
+	}
+
+	columns, col_err := strconv.Atoi(os.Getenv("COLUMNS"))
+	rows, row_err := strconv.Atoi(os.Getenv("LINES"))
+	if col_err == nil && row_err == nil && columns > 0 && rows > 0 {
+		return columns, rows, nil
+	}
+
+	return 0, 0, ErrNoTerminal
+}
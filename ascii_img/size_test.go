@@ -0,0 +1,83 @@
+package ascii_img
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestGridCoversImage(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          Size
+		width, height int
+		aspect        float64
+		columns, rows int
+	}{
+		{"sample divides", BySample(8), 80, 40, 1, 10, 5},
+		{"sample leaves a remainder", BySample(8), 1001, 43, 1, 126, 6},
+		{"tall cells", BySample(4), 30, 30, 0.5, 8, 4},
+		{"columns", ByColumns(3), 10, 10, 1, 3, 3},
+		{"rows", ByRows(4), 9, 10, 1, 4, 4},
+		{"fit", FitWithin(7, 100), 50, 20, 1, 7, 3},
+		{"whole image", BySample(10), 10, 10, 1, 1, 1},
+	}
+
+	for _, test := range tests {
+		columns, rows, stride_x, stride_y, err := test.size.Grid(test.width, test.height, test.aspect)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if columns != test.columns || rows != test.rows {
+			t.Errorf("%s: grid is %vx%v, want %vx%v", test.name, columns, rows, test.columns, test.rows)
+		}
+		// the last cell starts inside the image and the grid reaches its far edge
+		if float64(columns-1)*stride_x >= float64(test.width) || float64(columns)*stride_x < float64(test.width)-1e-6 {
+			t.Errorf("%s: %v columns of %v don't cover %v pixels", test.name, columns, stride_x, test.width)
+		}
+		if float64(rows-1)*stride_y >= float64(test.height) || float64(rows)*stride_y < float64(test.height)-1e-6 {
+			t.Errorf("%s: %v rows of %v don't cover %v pixels", test.name, rows, stride_y, test.height)
+		}
+	}
+
+	if _, _, _, _, err := BySample(11).Grid(10, 10, 1); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("sample larger than the image: got %v, want ErrInvalidSize", err)
+	}
+}
+
+func TestInitializeKeepsRemainder(t *testing.T) {
+	// 10x7, the last column and row are red
+	img := image.NewRGBA(image.Rect(0, 0, 10, 7))
+	for y := range 7 {
+		for x := range 10 {
+			c := color.RGBA{0, 0, 255, 255}
+			if x == 9 || y == 6 {
+				c = color.RGBA{255, 0, 0, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	arr, err := InitializeFromImageXY(img, 3, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(arr) != 3 || len(arr[0]) != 4 {
+		t.Fatalf("grid is %vx%v, want 4x3", len(arr[0]), len(arr))
+	}
+
+	for _, cell := range [][2]int{{0, 3}, {1, 3}, {2, 0}, {2, 3}} {
+		if p := arr[cell[0]][cell[1]]; p.R != 255 || p.B != 0 {
+			t.Errorf("remainder cell (%v, %v) is %v, want the red it covers", cell[1], cell[0], p)
+		}
+	}
+	if p := arr[1][1]; p.R != 0 || p.B != 255 {
+		t.Errorf("inner cell is %v, want blue", p)
+	}
+
+	if _, err := InitializeFromImageXY(img, 11, 3); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("sample wider than the image: got %v, want ErrInvalidSize", err)
+	}
+}
@@ -0,0 +1,275 @@
+package ascii_img
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// *****************
+// SVG OUTPUT
+// *****************
+
+type SVGOptions struct {
+	// user units each character takes up, like OutputImage's px_size (default 8). Characters are CellSize tall.
+	CellSize int
+	// width of every cell when it isn't square, like OutputImageCells' cell_width (0 is CellSize). Match the aspect the grid was sampled with.
+	CellWidth int
+	// fill characters with their pixel's color, otherwise they're white
+	Color bool
+	// background rectangle color (default black)
+	Background color.Color
+	// font-family of the <text> elements (default "monospace"), unused with Outlines
+	FontFamily string
+	// draw every glyph as a <path> traced from Font instead of <text>, so the file looks the same without the font installed
+	Outlines bool
+	// font the outlines are traced from, DefaultFont() if nil
+	Font *truetype.Font
+}
+
+/*
+Writes the array as an svg, every character sitting in a CellWidth x CellSize cell on a background rectangle. Characters are <text>
+elements (one per run of same colored characters in a row) or, with Outlines, <use>s of glyph <path>s. Cells without a character
+(rune 0, ex: XDoG) are drawn as filled <rect>s.
+*/
+func WriteSVG(w io.Writer, arr [][]transforms.Pixel, opts SVGOptions) error {
+	if opts.CellSize <= 0 {
+		opts.CellSize = 8
+	}
+	if opts.CellWidth <= 0 {
+		opts.CellWidth = opts.CellSize
+	}
+	if opts.Background == nil {
+		opts.Background = color.Black
+	}
+	if opts.FontFamily == "" {
+		opts.FontFamily = "monospace"
+	}
+
+	var glyphs *svgGlyphs
+	if opts.Outlines {
+		f := opts.Font
+		if f == nil {
+			var err error
+			if f, err = DefaultFont(); err != nil {
+				return err
+			}
+		}
+		glyphs = newSVGGlyphs(f, opts.CellSize)
+	}
+
+	rows := len(arr)
+	cols := 0
+	if rows > 0 {
+		cols = len(arr[0])
+	}
+	width, height := cols*opts.CellWidth, rows*opts.CellSize
+
+	bw := bufio.NewWriter(w)
+
+	r, g, b, _ := opts.Background.RGBA()
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n", width, height, width, height)
+	fmt.Fprintf(bw, "<rect width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", width, height, hexColor([3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}))
+
+	var body strings.Builder
+	if !opts.Outlines {
+		fmt.Fprintf(&body, "<g font-family=\"%s\" font-size=\"%d\">\n", html.EscapeString(opts.FontFamily), opts.CellSize)
+	}
+
+	for i := range rows {
+		baseline := (i + 1) * opts.CellSize
+
+		// run of same colored characters waiting to be written as one <text>
+		var run_text []rune
+		var run_x []int
+		var run_color [3]uint8
+		flush := func() {
+			if len(run_text) == 0 {
+				return
+			}
+			xs := make([]string, len(run_x))
+			for k, x := range run_x {
+				xs[k] = strconv.Itoa(x)
+			}
+			fmt.Fprintf(&body, "<text x=\"%s\" y=\"%d\" fill=\"%s\">%s</text>\n", strings.Join(xs, " "), baseline, hexColor(run_color), html.EscapeString(string(run_text)))
+			run_text, run_x = run_text[:0], run_x[:0]
+		}
+
+		for j := range len(arr[i]) {
+			cur := &arr[i][j]
+			x := j * opts.CellWidth
+
+			c := [3]uint8{255, 255, 255}
+			if opts.Color || cur.Character == 0 {
+				c = [3]uint8{cur.R, cur.G, cur.B}
+			}
+
+			switch {
+			case cur.Character == 0:
+				flush()
+				fmt.Fprintf(&body, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", x, baseline-opts.CellSize, opts.CellWidth, opts.CellSize, hexColor(c))
+			case cur.Character == ' ':
+				flush()
+			case opts.Outlines:
+				if id, ok := glyphs.id(cur.Character); ok {
+					fmt.Fprintf(&body, "<use xlink:href=\"#%s\" x=\"%d\" y=\"%d\" fill=\"%s\"/>\n", id, x, baseline, hexColor(c))
+				}
+			default:
+				if len(run_text) > 0 && run_color != c {
+					flush()
+				}
+				run_text = append(run_text, cur.Character)
+				run_x = append(run_x, x)
+				run_color = c
+			}
+		}
+		flush()
+	}
+
+	if !opts.Outlines {
+		body.WriteString("</g>\n")
+	}
+
+	if glyphs != nil && len(glyphs.paths) > 0 {
+		bw.WriteString("<defs>\n")
+		for _, path := range glyphs.paths {
+			bw.WriteString(path)
+		}
+		bw.WriteString("</defs>\n")
+	}
+
+	bw.WriteString(body.String())
+	bw.WriteString("</svg>\n")
+
+	return bw.Flush()
+}
+
+// glyph outlines traced so far, each rune is traced once
+type svgGlyphs struct {
+	font  *truetype.Font
+	scale fixed.Int26_6
+	buf   truetype.GlyphBuf
+	ids   map[rune]string
+	paths []string
+}
+
+func newSVGGlyphs(f *truetype.Font, cell_size int) *svgGlyphs {
+	return &svgGlyphs{font: f, scale: fixed.Int26_6(cell_size << 6), ids: make(map[rune]string)}
+}
+
+// id of the <path> for r, false if the glyph has no outline
+func (glyphs *svgGlyphs) id(r rune) (string, bool) {
+	if id, ok := glyphs.ids[r]; ok {
+		return id, id != ""
+	}
+
+	glyphs.ids[r] = ""
+	if err := glyphs.buf.Load(glyphs.font, glyphs.scale, glyphs.font.Index(r), font.HintingNone); err != nil {
+		return "", false
+	}
+
+	d := outlinePath(glyphs.buf.Points, glyphs.buf.Ends)
+	if d == "" {
+		return "", false
+	}
+
+	id := fmt.Sprintf("g%d", len(glyphs.paths))
+	glyphs.ids[r] = id
+	glyphs.paths = append(glyphs.paths, fmt.Sprintf("<path id=\"%s\" d=\"%s\"/>\n", id, d))
+
+	return id, true
+}
+
+/*
+Traces TrueType contours into svg path data, relative to the glyph's baseline origin. TrueType contours are quadratic splines where two
+off-curve points in a row have an implied on-curve point halfway between them.
+*/
+func outlinePath(points []truetype.Point, ends []int) string {
+	var sb strings.Builder
+
+	start := 0
+	for _, end := range ends {
+		contour := points[start:end]
+		start = end
+		if len(contour) == 0 {
+			continue
+		}
+
+		// start from an on-curve point, or the midpoint of the first two off-curve points if there is none
+		first := -1
+		for k, p := range contour {
+			if p.Flags&1 != 0 {
+				first = k
+				break
+			}
+		}
+
+		var origin [2]fixed.Int26_6
+		if first >= 0 {
+			origin = svgPoint(contour[first])
+		} else {
+			first = 0
+			origin = midpoint(svgPoint(contour[0]), svgPoint(contour[1%len(contour)]))
+		}
+		writePathCmd(&sb, "M", origin)
+
+		var control [2]fixed.Int26_6
+		has_control := false
+		for k := 1; k <= len(contour); k++ {
+			p := contour[(first+k)%len(contour)]
+			pt := svgPoint(p)
+
+			if p.Flags&1 != 0 {
+				if has_control {
+					writePathCmd(&sb, "Q", control, pt)
+				} else {
+					writePathCmd(&sb, "L", pt)
+				}
+				has_control = false
+				continue
+			}
+
+			if has_control {
+				writePathCmd(&sb, "Q", control, midpoint(control, pt))
+			}
+			control, has_control = pt, true
+		}
+
+		if has_control {
+			writePathCmd(&sb, "Q", control, origin)
+		}
+		sb.WriteString("Z")
+	}
+
+	return sb.String()
+}
+
+// font units are y-up, svg is y-down
+func svgPoint(p truetype.Point) [2]fixed.Int26_6 {
+	return [2]fixed.Int26_6{p.X, -p.Y}
+}
+
+func midpoint(a [2]fixed.Int26_6, b [2]fixed.Int26_6) [2]fixed.Int26_6 {
+	return [2]fixed.Int26_6{(a[0] + b[0]) / 2, (a[1] + b[1]) / 2}
+}
+
+func writePathCmd(sb *strings.Builder, cmd string, pts ...[2]fixed.Int26_6) {
+	sb.WriteString(cmd)
+	for k, pt := range pts {
+		if k > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(pt[0])/64, 'f', -1, 64))
+		sb.WriteByte(' ')
+		sb.WriteString(strconv.FormatFloat(float64(pt[1])/64, 'f', -1, 64))
+	}
+}
@@ -0,0 +1,33 @@
+package ascii_img
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+)
+
+func TestSVGCellWidth(t *testing.T) {
+	arr := [][]transforms.Pixel{
+		{{Character: 'a'}, {Character: 'b'}, {Character: 0}},
+		{{Character: 'c'}, {Character: 'd'}, {Character: 'e'}},
+	}
+
+	var out bytes.Buffer
+	if err := WriteSVG(&out, arr, SVGOptions{CellSize: 8, CellWidth: 4}); err != nil {
+		t.Fatal(err)
+	}
+	svg := out.String()
+
+	for _, want := range []string{
+		`width="12" height="16"`,
+		`<text x="0 4" y="8" fill="#ffffff">ab</text>`,
+		`<rect x="8" y="0" width="4" height="8"`,
+		`<text x="0 4 8" y="16" fill="#ffffff">cde</text>`,
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("svg is missing %s:\n%s", want, svg)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+//go:build linux
+
+package ascii_img
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// struct winsize from <sys/ioctl.h>
+type winsize struct {
+	rows, columns  uint16
+	xpixel, ypixel uint16
+}
+
+func terminalSize(f *os.File) (columns int, rows int, err error) {
+	var ws winsize
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0, 0, errno
+	}
+
+	return int(ws.columns), int(ws.rows), nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package ascii_img
+
+import "os"
+
+// only linux asks the terminal, TerminalSize falls back to COLUMNS and LINES elsewhere
+func terminalSize(f *os.File) (columns int, rows int, err error) {
+	return 0, 0, ErrNoTerminal
+}
@@ -0,0 +1,249 @@
+package ascii_img
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// *****************
+// TRUETYPE SUBSETTING
+// *****************
+
+// tables kept in a subset. PDF only needs the outlines and metrics, cmap, name, OS/2 and post keep the subset a valid standalone font.
+var subsetTables = []string{"OS/2", "cmap", "cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name", "post", "prep"}
+
+type ttfTable struct {
+	tag  string
+	data []byte
+}
+
+/*
+Subsets a TrueType font down to the glyphs in keep (plus .notdef and any composite glyph components). Glyph ids don't change: dropped
+glyphs are left empty, so the font shrinks without having to renumber anything.
+*/
+func subsetTTF(font_bytes []byte, keep map[uint16]bool) ([]byte, error) {
+	tables, err := readTTFTables(font_bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	head, maxp := tables["head"], tables["maxp"]
+	loca, glyf := tables["loca"], tables["glyf"]
+	if len(head) < 54 || len(maxp) < 6 || loca == nil || glyf == nil {
+		return nil, fmt.Errorf("%w: font is missing TrueType outline tables", ErrFontLoad)
+	}
+
+	num_glyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	long_loca := binary.BigEndian.Uint16(head[50:]) == 1
+
+	offsets := make([]uint32, num_glyphs+1)
+	for i := range offsets {
+		switch {
+		case long_loca && len(loca) >= 4*(i+1):
+			offsets[i] = binary.BigEndian.Uint32(loca[4*i:])
+		case !long_loca && len(loca) >= 2*(i+1):
+			offsets[i] = uint32(binary.BigEndian.Uint16(loca[2*i:])) * 2
+		default:
+			return nil, fmt.Errorf("%w: loca table too short", ErrFontLoad)
+		}
+	}
+
+	glyph := func(id uint16) []byte {
+		if int(id) >= num_glyphs {
+			return nil
+		}
+		start, end := offsets[id], offsets[id+1]
+		if start >= end || int(end) > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	// .notdef and every component of kept composite glyphs are needed too
+	kept := map[uint16]bool{0: true}
+	pending := make([]uint16, 0, len(keep)+1)
+	pending = append(pending, 0)
+	for id := range keep {
+		pending = append(pending, id)
+	}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		kept[id] = true
+
+		for _, component := range compositeComponents(glyph(id)) {
+			if !kept[component] {
+				pending = append(pending, component)
+			}
+		}
+	}
+
+	new_glyf := make([]byte, 0, len(glyf))
+	new_loca := make([]byte, 4*(num_glyphs+1))
+	for id := range num_glyphs {
+		binary.BigEndian.PutUint32(new_loca[4*id:], uint32(len(new_glyf)))
+		if kept[uint16(id)] {
+			new_glyf = append(new_glyf, glyph(uint16(id))...)
+			for len(new_glyf)%4 != 0 {
+				new_glyf = append(new_glyf, 0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(new_loca[4*num_glyphs:], uint32(len(new_glyf)))
+
+	new_head := append([]byte(nil), head...)
+	binary.BigEndian.PutUint16(new_head[50:], 1) // long loca offsets
+	binary.BigEndian.PutUint32(new_head[8:], 0)  // checkSumAdjustment, set once the file is built
+
+	out := make([]ttfTable, 0, len(subsetTables))
+	for _, tag := range subsetTables {
+		data, ok := tables[tag]
+		switch tag {
+		case "glyf":
+			data = new_glyf
+		case "loca":
+			data = new_loca
+		case "head":
+			data = new_head
+		}
+		if ok {
+			out = append(out, ttfTable{tag, data})
+		}
+	}
+
+	font := writeTTF(out)
+
+	// head's checkSumAdjustment makes the whole file sum to 0xB1B0AFBA
+	head_offset := binary.BigEndian.Uint32(font[12+16*tableIndex(out, "head")+8:])
+	binary.BigEndian.PutUint32(font[head_offset+8:], 0xB1B0AFBA-ttfChecksum(font))
+
+	return font, nil
+}
+
+func tableIndex(tables []ttfTable, tag string) int {
+	for i, table := range tables {
+		if table.tag == tag {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func readTTFTables(font_bytes []byte) (map[string][]byte, error) {
+	if len(font_bytes) < 12 {
+		return nil, fmt.Errorf("%w: font too short", ErrFontLoad)
+	}
+
+	num_tables := int(binary.BigEndian.Uint16(font_bytes[4:]))
+	if len(font_bytes) < 12+16*num_tables {
+		return nil, fmt.Errorf("%w: table directory too short", ErrFontLoad)
+	}
+
+	tables := make(map[string][]byte, num_tables)
+	for i := range num_tables {
+		record := font_bytes[12+16*i:]
+		tag := string(record[:4])
+		offset := binary.BigEndian.Uint32(record[8:])
+		length := binary.BigEndian.Uint32(record[12:])
+
+		if uint64(offset)+uint64(length) > uint64(len(font_bytes)) {
+			return nil, fmt.Errorf("%w: table %q out of bounds", ErrFontLoad, tag)
+		}
+		tables[tag] = font_bytes[offset : offset+length]
+	}
+
+	return tables, nil
+}
+
+// glyph ids a composite glyph is built from, none for simple glyphs
+func compositeComponents(glyph []byte) []uint16 {
+	if len(glyph) < 10 || int16(binary.BigEndian.Uint16(glyph)) >= 0 {
+		return nil
+	}
+
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		have2x2        = 0x0080
+	)
+
+	var components []uint16
+	pos := 10
+	for pos+4 <= len(glyph) {
+		flags := binary.BigEndian.Uint16(glyph[pos:])
+		components = append(components, binary.BigEndian.Uint16(glyph[pos+2:]))
+		pos += 4
+
+		if flags&argsAreWords != 0 {
+			pos += 4
+		} else {
+			pos += 2
+		}
+
+		switch {
+		case flags&haveScale != 0:
+			pos += 2
+		case flags&haveXYScale != 0:
+			pos += 4
+		case flags&have2x2 != 0:
+			pos += 8
+		}
+
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+
+	return components
+}
+
+// builds a font file (table directory + 4 byte aligned tables) out of tables
+func writeTTF(tables []ttfTable) []byte {
+	sort.Slice(tables, func(i, j int) bool { return tables[i].tag < tables[j].tag })
+
+	num_tables := len(tables)
+	entry_selector := 0
+	for 1<<(entry_selector+1) <= num_tables {
+		entry_selector++
+	}
+	search_range := (1 << entry_selector) * 16
+
+	header := make([]byte, 12+16*num_tables)
+	binary.BigEndian.PutUint32(header[0:], 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(num_tables))
+	binary.BigEndian.PutUint16(header[6:], uint16(search_range))
+	binary.BigEndian.PutUint16(header[8:], uint16(entry_selector))
+	binary.BigEndian.PutUint16(header[10:], uint16(num_tables*16-search_range))
+
+	font := header
+	for i, table := range tables {
+		record := header[12+16*i:]
+		copy(record, table.tag)
+		binary.BigEndian.PutUint32(record[4:], ttfChecksum(table.data))
+		binary.BigEndian.PutUint32(record[8:], uint32(len(font)))
+		binary.BigEndian.PutUint32(record[12:], uint32(len(table.data)))
+
+		font = append(font, table.data...)
+		for len(font)%4 != 0 {
+			font = append(font, 0)
+		}
+		header = font[:12+16*num_tables]
+	}
+
+	return font
+}
+
+func ttfChecksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+
+	return sum
+}
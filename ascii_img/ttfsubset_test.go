@@ -0,0 +1,84 @@
+package ascii_img
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func TestSubsetTTF(t *testing.T) {
+	font_bytes, err := FontBytes(DefaultFontName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := ParseFont(font_bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keep := make(map[uint16]bool)
+	for _, r := range "@#%ab." {
+		keep[uint16(original.Index(r))] = true
+	}
+
+	subset_bytes, err := subsetTTF(font_bytes, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subset_bytes) >= len(font_bytes) {
+		t.Errorf("subset is %v bytes, the font is %v", len(subset_bytes), len(font_bytes))
+	}
+	if sum := ttfChecksum(subset_bytes); sum != 0xB1B0AFBA {
+		t.Errorf("file checksum is %#x, want 0xb1b0afba", sum)
+	}
+
+	subset, err := truetype.Parse(subset_bytes)
+	if err != nil {
+		t.Fatalf("subset doesn't parse: %v", err)
+	}
+
+	// glyph ids are kept, so the glyph count doesn't change
+	original_tables, err := readTTFTables(font_bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subset_tables, err := readTTFTables(subset_bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	num_glyphs := binary.BigEndian.Uint16(original_tables["maxp"][4:])
+	if got := binary.BigEndian.Uint16(subset_tables["maxp"][4:]); got != num_glyphs {
+		t.Fatalf("subset has %v glyphs, want %v", got, num_glyphs)
+	}
+
+	units := fixed.Int26_6(original.FUnitsPerEm())
+	var want_glyph, got_glyph truetype.GlyphBuf
+	for id := range num_glyphs {
+		index := truetype.Index(id)
+		if got, want := subset.HMetric(units, index), original.HMetric(units, index); got != want {
+			t.Errorf("glyph %v: advance %v, want %v", id, got, want)
+		}
+
+		if err := got_glyph.Load(subset, units, index, font.HintingNone); err != nil {
+			t.Fatalf("glyph %v doesn't load from the subset: %v", id, err)
+		}
+		if !keep[id] && id != 0 {
+			continue
+		}
+		if err := want_glyph.Load(original, units, index, font.HintingNone); err != nil {
+			t.Fatal(err)
+		}
+		if len(got_glyph.Points) != len(want_glyph.Points) {
+			t.Errorf("kept glyph %v has %v points, want %v", id, len(got_glyph.Points), len(want_glyph.Points))
+		}
+	}
+
+	for _, r := range "@#%ab." {
+		if subset.Index(r) != original.Index(r) {
+			t.Errorf("%q maps to glyph %v in the subset, %v in the font", r, subset.Index(r), original.Index(r))
+		}
+	}
+}
@@ -12,10 +12,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/RohanPalivela/ascii_image_manip/ascii_img"
 	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
 	"github.com/golang/freetype"
 )
 
+// run with "go run ." from the repo root, see cmd/asciify for converting images and "go test -bench ." in ascii_img for the sampling benchmarks
+func main() {
+	benchmark()
+}
+
 // function that performs rudimentary time benchmarking
 func benchmark() {
 	// CONSTANTS FOR PROGRAM
@@ -64,8 +70,11 @@ func benchmark() {
 	// GetRunes(arr)
 	// arr = transforms.XDoG(arr)
 	// arr = transforms.DoG(arr, 1, 15)
-	transforms.AsciiFilter(arr, 1, 15)
-	// transforms.NaiveAsciiFilter(arr)
+	if err := transforms.AsciiFilter(arr, transforms.StandardRamp(), 1, 15); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	// transforms.NaiveAsciiFilter(arr, transforms.StandardRamp())
 	// arr = transforms.SobelFilter(arr, false)
 
 	// for i := range len(arr) {
@@ -90,16 +99,43 @@ func benchmark() {
 
 	context := InitializeContext(newimg, float64(px_size))
 
-	buffer := transforms.InitializeBuffer(0, px_size, out_width, out_height, px_size, newimg)
+	buffer := transforms.InitializeBuffer(0, px_size, out_width, out_height, px_size, px_size, newimg)
 
 	LogOut(fmt.Sprintf("LOGGING >> Did pre-processing for image drawing (blank image, created image buffer, parsed font): %s", time.Since(intermediate)))
 	intermediate = time.Now()
 
-	buffer.WriteArray(context, arr, true)
+	if err := buffer.WriteArray(context, arr, true); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
 
 	LogOut(fmt.Sprintf("LOGGING >> Took %s to draw pixels in buffer", time.Since(intermediate)))
 	intermediate = time.Now()
 
+	// same drawing with the glyph atlas, rasterizing every glyph once
+	font, err := ascii_img.DefaultFont()
+	if err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+	atlas := transforms.NewGlyphAtlas(font, float64(px_size), transforms.StandardRamp())
+
+	LogOut(fmt.Sprintf("LOGGING >> Took %s to rasterize the glyph atlas", time.Since(intermediate)))
+	intermediate = time.Now()
+
+	atlas_img := image.NewRGBA(newimg.Bounds())
+	draw.Draw(atlas_img, atlas_img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	atlas_buffer := transforms.InitializeBuffer(0, px_size, out_width, out_height, px_size, px_size, atlas_img)
+	atlas_start := time.Now()
+
+	if err := atlas_buffer.WriteArrayAtlas(atlas, arr, true, true); err != nil {
+		log.Println(err)
+		os.Exit(1)
+	}
+
+	LogOut(fmt.Sprintf("LOGGING >> Took %s to draw pixels in buffer with the glyph atlas (same image: %v)", time.Since(atlas_start), string(atlas_img.Pix) == string(newimg.Pix)))
+	intermediate = time.Now()
+
 	op_end := time.Since(operations)
 
 	outFile, err := os.Create("out.png")
@@ -330,18 +366,5 @@ func InitializeArray(img image.Image, sample_size int, pix_height int, pix_width
 
 func GetRunes(arr [][]transforms.Pixel) {
 	// luminescence to ascii mapping
-	mapping := map[int]rune{
-		0: ' ',
-		1: '.',
-		2: ':',
-		3: 'c',
-		4: 'o',
-		5: 'C',
-		6: 'O',
-		7: '0',
-		8: '@',
-		9: '■',
-	}
-
-	transforms.LuminFilter(arr, mapping)
+	transforms.LuminFilter(arr, transforms.StandardRamp())
 }
@@ -0,0 +1,795 @@
+// Command asciify converts images into ascii art.
+//
+// Usage:
+//
+//	asciify render [flags] -in <image>
+//	asciify text [flags] -in <image>
+//	asciify filters
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/RohanPalivela/ascii_image_manip/ascii_img"
+	transforms "github.com/RohanPalivela/ascii_image_manip/transforms"
+	"github.com/golang/freetype/truetype"
+)
+
+type filterInfo struct {
+	make        func(opts *options) ascii_img.Option
+	description string
+}
+
+var filters = map[string]filterInfo{
+	"ascii": {
+		make: func(opts *options) ascii_img.Option {
+			return ascii_img.WithFilter(ascii_img.AsciiFilterEdges(opts.edgeOptions()))
+		},
+		description: "AsciiFilter: luminance ramp with DoG + sobel edges (or Canny, -edges canny)",
+	},
+	"fullres": {
+		make: func(opts *options) ascii_img.Option {
+			return ascii_img.WithSourceFilter(ascii_img.FullResAsciiFilterEdges(opts.edgeOptions(), opts.edge_threshold))
+		},
+		description: "FullResAsciiFilter: AsciiFilter with edges found at full resolution, voted per cell (-edge-threshold)",
+	},
+	"shape": {
+		make: func(opts *options) ascii_img.Option {
+			return ascii_img.WithSourceFilter(ascii_img.ShapeFilter(opts.shape_font, opts.shape_x, opts.shape_y))
+		},
+		description: "ShapeFilter: characters matched to the shape of each cell's pixels (-shape-grid), no edge pass",
+	},
+	"naive": {
+		make: func(opts *options) ascii_img.Option {
+			return ascii_img.WithFilter(ascii_img.NaiveAsciiFilterEdges(opts.edgeOptions()))
+		},
+		description: "NaiveAsciiFilter: luminance ramp with sobel edges, no DoG (-edge-cutoff, -gradient)",
+	},
+	"noedges": {
+		make: func(opts *options) ascii_img.Option {
+			return ascii_img.WithFilter(ascii_img.NoEdgesFilterEdges(opts.edgeOptions()))
+		},
+		description: "NoEdgesFilter: luminance ramp with sobel edges (-edge-cutoff, -gradient)",
+	},
+	"xdog": {
+		make:        func(opts *options) ascii_img.Option { return ascii_img.WithFilter(ascii_img.XDoGFilter()) },
+		description: "XDoG: extended difference of gaussians, drawn as solid cells",
+	},
+	"lumin": {
+		make:        func(opts *options) ascii_img.Option { return ascii_img.WithFilter(ascii_img.LuminFilter()) },
+		description: "LuminFilter: luminance ramp only",
+	},
+}
+
+type options struct {
+	input            string
+	output           string
+	sample_size      int
+	px_size          int
+	filter           string
+	blur_1           int
+	blur_2           int
+	dog_offset       float64
+	color            bool
+	format           string
+	quality          int
+	font             string
+	verbose          bool
+	ramp_arg         string
+	ramp             transforms.Ramp
+	auto_ramp        bool
+	levels           int
+	background       bool
+	palette          string
+	dither           bool
+	background_color string
+	font_stack       string
+	embed_font       string
+	outlines         bool
+	page             string
+	dpi              float64
+	margin           float64
+	crop_marks       bool
+	aspect_arg       string
+	columns          int
+	rows             int
+	terminal         bool
+	resample_arg     string
+	resampler        ascii_img.Resampler
+	workers          int
+	edge_threshold   float64
+	edges_arg        string
+	edge_cutoff_arg  string
+	edge_cutoff      transforms.Threshold
+	gradient_arg     string
+	gradient         *transforms.GradientOperator
+	kernel_x         string
+	kernel_y         string
+	tensor_blur      int
+	min_coherence    float64
+	edge_detector    transforms.EdgeDetector
+	canny_blur       int
+	canny_low        float64
+	canny_high       float64
+	shape_grid       string
+	shape_x          int
+	shape_y          int
+	shape_font       *truetype.Font
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "render":
+		err = render(os.Args[2:])
+	case "text":
+		err = text(os.Args[2:])
+	case "html":
+		err = writeHTML(os.Args[2:])
+	case "svg":
+		err = writeSVG(os.Args[2:])
+	case "pdf":
+		err = writePDF(os.Args[2:])
+	case "filters":
+		listFilters()
+	case "ramps":
+		listRamps()
+	case "fonts":
+		listFonts()
+	case "help", "-h", "-help", "--help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "asciify: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "asciify: "+err.Error())
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `Usage: asciify <command> [flags]
+
+Commands:
+  render   convert a png, jpeg or gif into an ascii art png/jpeg
+  text     convert an image into plain text ascii art
+  html     convert an image into a standalone colored html page
+  svg      convert an image into an svg
+  pdf      convert an image into a print ready pdf, tiled across pages if needed
+  filters  list the available filters
+  ramps    list the built-in character ramps
+  fonts    list the bundled fonts
+
+Run "asciify <command> -h" for the flags of a command.`)
+}
+
+// registers the flags shared by every converting command
+func commonFlags(set *flag.FlagSet, opts *options) {
+	set.StringVar(&opts.input, "in", "", "path of the `image` to convert (png, jpeg or gif, detected from its contents)")
+	set.IntVar(&opts.sample_size, "sample", 8, "average every NxN block of the image into one character")
+	set.IntVar(&opts.columns, "cols", 0, "make the output this many characters wide instead of using -sample")
+	set.IntVar(&opts.rows, "rows", 0, "make the output this many characters tall, with -cols fit within both")
+	set.BoolVar(&opts.terminal, "term", false, "fit the output in the current terminal instead of using -sample")
+	set.IntVar(&opts.workers, "workers", 0, "goroutines each stage is split across, 0 uses every CPU")
+	set.StringVar(&opts.resample_arg, "resample", "box", "how pixels are sampled: box, area, bilinear, catmull-rom or lanczos3")
+	set.StringVar(&opts.filter, "filter", "ascii", "filter to apply, see \"asciify filters\"")
+	set.IntVar(&opts.blur_1, "blur1", 1, "kernel size of the smaller DoG blur (odd)")
+	set.IntVar(&opts.blur_2, "blur2", 15, "kernel size of the larger DoG blur (odd)")
+	set.Float64Var(&opts.dog_offset, "dog-offset", 15, "with -edges dog, brightness (0-255) added to every DoG response before the sobel pass")
+	set.StringVar(&opts.edges_arg, "edges", "dog", "with -filter ascii or fullres, edge detector: dog or canny")
+	set.StringVar(&opts.edge_cutoff_arg, "edge-cutoff", "100", "with -edges dog or -filter naive/noedges, sobel magnitude an edge must be above: a number 0-255, \"otsu\" or the strongest percent like \"10%\"")
+	set.StringVar(&opts.gradient_arg, "gradient", "sobel", "with -filter ascii, fullres, naive or noedges, gradient kernels edges are found with: sobel, sobel5, scharr, prewitt or roberts")
+	set.StringVar(&opts.kernel_x, "kernel-x", "", "with -kernel-y, custom gradient `kernel` for brightness growing rightwards in place of -gradient, rows split by ';' and values by ',' (ex: \"-1,0,1;-2,0,2;-1,0,1\")")
+	set.StringVar(&opts.kernel_y, "kernel-y", "", "with -kernel-x, custom gradient `kernel` for brightness growing upwards, the same size as -kernel-x (ex: \"1,2,1;0,0,0;-1,-2,-1\")")
+	set.IntVar(&opts.tensor_blur, "tensor-blur", 0, "with -filter ascii, fullres, naive or noedges, kernel size (odd) of the structure tensor smoothing edge directions along contours, 0 is off")
+	set.Float64Var(&opts.min_coherence, "min-coherence", 0, "with -tensor-blur, drop edges less coherent than this (0-1), texture rather than contours")
+	set.IntVar(&opts.canny_blur, "canny-blur", 5, "with -edges canny, kernel size of the blur before finding edges (odd)")
+	set.Float64Var(&opts.canny_low, "canny-low", 40, "with -edges canny, gradient magnitude an edge may fade down to while connected to a strong one")
+	set.Float64Var(&opts.canny_high, "canny-high", 100, "with -edges canny, gradient magnitude that starts an edge")
+	set.Float64Var(&opts.edge_threshold, "edge-threshold", 0.05, "with -filter fullres, share of a cell's pixels that must agree on an edge direction (0-1)")
+	set.StringVar(&opts.shape_grid, "shape-grid", "2x3", "with -filter shape, regions each cell is compared over, `COLSxROWS`")
+	set.BoolVar(&opts.verbose, "v", false, "print how long each stage took to stderr")
+	set.StringVar(&opts.ramp_arg, "ramp", "standard", "built-in ramp name (see \"asciify ramps\") or the characters to use, least dense first")
+}
+
+func parse(set *flag.FlagSet, args []string, opts *options) error {
+	if err := set.Parse(args); err != nil {
+		return err
+	}
+
+	if opts.input == "" {
+		return fmt.Errorf("missing -in image")
+	}
+
+	if opts.sample_size < 1 {
+		return fmt.Errorf("-sample must be at least 1, got %v", opts.sample_size)
+	}
+
+	if opts.columns < 0 || opts.rows < 0 {
+		return fmt.Errorf("-cols and -rows must be positive, got %v and %v", opts.columns, opts.rows)
+	}
+
+	if opts.terminal && (opts.columns > 0 || opts.rows > 0) {
+		return fmt.Errorf("-term can't be combined with -cols or -rows")
+	}
+
+	transforms.SetWorkers(opts.workers)
+
+	resampler, err := ascii_img.ParseResampler(opts.resample_arg)
+	if err != nil {
+		return err
+	}
+	opts.resampler = resampler
+
+	ramp, err := transforms.ParseRamp(opts.ramp_arg)
+	if err != nil {
+		return err
+	}
+	opts.ramp = ramp
+
+	if _, ok := filters[opts.filter]; !ok {
+		return fmt.Errorf("unknown filter %q, run \"asciify filters\"", opts.filter)
+	}
+
+	if opts.edge_threshold < 0 || opts.edge_threshold > 1 {
+		return fmt.Errorf("-edge-threshold must be between 0 and 1, got %v", opts.edge_threshold)
+	}
+
+	edge_detector, err := transforms.ParseEdgeDetector(opts.edges_arg)
+	if err != nil {
+		return err
+	}
+	opts.edge_detector = edge_detector
+
+	edge_cutoff, err := transforms.ParseThreshold(opts.edge_cutoff_arg)
+	if err != nil {
+		return fmt.Errorf("-edge-cutoff: %w", err)
+	}
+	opts.edge_cutoff = edge_cutoff
+
+	gradient, err := parseGradient(opts)
+	if err != nil {
+		return err
+	}
+	opts.gradient = gradient
+
+	if opts.tensor_blur < 0 || (opts.tensor_blur > 0 && opts.tensor_blur%2 == 0) {
+		return fmt.Errorf("-tensor-blur must be odd, or 0 to turn it off, got %v", opts.tensor_blur)
+	}
+
+	if opts.min_coherence < 0 || opts.min_coherence > 1 {
+		return fmt.Errorf("-min-coherence must be between 0 and 1, got %v", opts.min_coherence)
+	}
+
+	if opts.canny_blur%2 == 0 || opts.canny_blur < 1 {
+		return fmt.Errorf("-canny-blur must be odd and positive, got %v", opts.canny_blur)
+	}
+
+	if opts.canny_low < 0 || opts.canny_low > opts.canny_high {
+		return fmt.Errorf("-canny-low must be between 0 and -canny-high, got %v and %v", opts.canny_low, opts.canny_high)
+	}
+
+	if opts.filter == "shape" {
+		if err := parseShape(opts); err != nil {
+			return err
+		}
+	}
+
+	if opts.blur_1%2 == 0 || opts.blur_2%2 == 0 || opts.blur_1 < 1 || opts.blur_2 < 1 {
+		return fmt.Errorf("-blur1 and -blur2 must be odd and positive, got %v and %v", opts.blur_1, opts.blur_2)
+	}
+
+	return nil
+}
+
+func render(args []string) error {
+	var opts options
+	set := flag.NewFlagSet("render", flag.ExitOnError)
+	commonFlags(set, &opts)
+	set.StringVar(&opts.output, "out", "out", "output file name, without extension")
+	set.IntVar(&opts.px_size, "px", 8, "size in pixels of each character in the output")
+	set.BoolVar(&opts.color, "color", true, "draw characters in the color of the image")
+	set.StringVar(&opts.format, "format", "png", "output format: png or jpeg")
+	set.IntVar(&opts.quality, "quality", 90, "jpeg quality (1-100)")
+	set.StringVar(&opts.font, "font", ascii_img.DefaultFontName, "bundled `font` name (see \"asciify fonts\") or path of a TrueType font to draw with")
+	set.BoolVar(&opts.auto_ramp, "auto-ramp", false, "reorder the -ramp characters by how much of a cell they cover in the font")
+	set.IntVar(&opts.levels, "levels", 0, "with -auto-ramp, keep only this many evenly spaced characters, at least 2 (0 keeps all)")
+	set.StringVar(&opts.aspect_arg, "aspect", "1", "width over height of each character cell, or \"font\" to use the font's proportions")
+
+	if err := parse(set, args, &opts); err != nil {
+		return err
+	}
+
+	if opts.px_size < 1 {
+		return fmt.Errorf("-px must be at least 1, got %v", opts.px_size)
+	}
+
+	aspect, err := parseAspect(opts.aspect_arg)
+	if err != nil {
+		return err
+	}
+
+	converter_opts := []ascii_img.Option{
+		ascii_img.WithCellSize(opts.px_size),
+		ascii_img.WithCellAspect(aspect),
+		ascii_img.WithColor(opts.color),
+	}
+
+	font, err := ascii_img.LoadFont(opts.font)
+	if err != nil {
+		return err
+	}
+	converter_opts = append(converter_opts, ascii_img.WithFont(font))
+
+	if opts.auto_ramp {
+		converter_opts = append(converter_opts, ascii_img.WithMeasuredRamp(opts.ramp, opts.levels))
+	}
+
+	res, err := convert(&opts, converter_opts...)
+	if err != nil {
+		return err
+	}
+
+	newimg := res.Image
+
+	var name string
+	switch strings.ToLower(opts.format) {
+	case "png":
+		name, err = ascii_img.CreatePNG(opts.output, newimg)
+	case "jpeg", "jpg":
+		name, err = ascii_img.CreateJPEG(opts.output, newimg, opts.quality)
+	default:
+		return fmt.Errorf("unknown format %q, use png or jpeg", opts.format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Created " + name)
+
+	return nil
+}
+
+func text(args []string) error {
+	var opts options
+	set := flag.NewFlagSet("text", flag.ExitOnError)
+	commonFlags(set, &opts)
+	set.StringVar(&opts.output, "out", "", "output text file, stdout if empty")
+	set.BoolVar(&opts.color, "color", false, "color characters with 24-bit ANSI escape sequences")
+	set.BoolVar(&opts.background, "bg", false, "with -color, color the cell behind each character instead")
+	set.StringVar(&opts.palette, "palette", "truecolor", "with -color, colors the terminal supports: truecolor, 256 or 16")
+	set.BoolVar(&opts.dither, "dither", false, "with -palette 256 or 16, diffuse the color error across cells")
+	set.StringVar(&opts.aspect_arg, "aspect", "0.5", "width over height of a terminal character cell")
+
+	if err := parse(set, args, &opts); err != nil {
+		return err
+	}
+
+	mode, err := ascii_img.ParseColorMode(opts.palette)
+	if err != nil {
+		return err
+	}
+
+	aspect, err := parseAspect(opts.aspect_arg)
+	if err != nil {
+		return err
+	}
+	if aspect == 0 {
+		return fmt.Errorf("-aspect must be a number for text output")
+	}
+
+	res, err := convert(&opts, ascii_img.WithRender(false), ascii_img.WithCellAspect(aspect))
+	if err != nil {
+		return err
+	}
+
+	arr := res.Grid
+
+	if opts.filter == "xdog" {
+		// xdog leaves no characters behind, map its output back onto the ramp
+		ascii_img.GetRunes(arr, opts.ramp)
+	}
+
+	out := os.Stdout
+	if opts.output != "" {
+		out, err = os.Create(opts.output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	if opts.color {
+		return ascii_img.WriteANSI(out, arr, ascii_img.ANSIOptions{
+			Mode:       mode,
+			Background: opts.background,
+			Dither:     opts.dither,
+		})
+	}
+
+	_, err = out.WriteString(ascii_img.ToText(arr))
+	return err
+}
+
+func writeHTML(args []string) error {
+	var opts options
+	set := flag.NewFlagSet("html", flag.ExitOnError)
+	commonFlags(set, &opts)
+	set.StringVar(&opts.output, "out", "", "output html file, stdout if empty")
+	set.IntVar(&opts.px_size, "px", 8, "size in pixels of each character on the page")
+	set.BoolVar(&opts.color, "color", true, "color characters with the colors of the image")
+	set.StringVar(&opts.background_color, "bg", "#000000", "page background `color` (#rrggbb)")
+	set.StringVar(&opts.font_stack, "font-stack", "monospace", "CSS font-family list for the characters")
+	set.StringVar(&opts.embed_font, "embed-font", "", "bundled font name or TrueType path to embed in the page")
+	set.StringVar(&opts.aspect_arg, "aspect", "1", "width over height of each character cell, or \"font\" to use the font's proportions")
+
+	if err := parse(set, args, &opts); err != nil {
+		return err
+	}
+
+	background, err := parseHexColor(opts.background_color)
+	if err != nil {
+		return err
+	}
+
+	aspect, cell_width, err := cellShape(&opts, opts.embed_font)
+	if err != nil {
+		return err
+	}
+
+	res, err := convert(&opts, ascii_img.WithRender(false), ascii_img.WithCellAspect(aspect))
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if opts.output != "" {
+		out, err = os.Create(opts.output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	return ascii_img.WriteHTML(out, res.Grid, ascii_img.HTMLOptions{
+		CellSize:   opts.px_size,
+		CellWidth:  cell_width,
+		FontStack:  opts.font_stack,
+		Background: background,
+		Color:      opts.color,
+		EmbedFont:  opts.embed_font,
+		Title:      filepath.Base(opts.input),
+	})
+}
+
+func writeSVG(args []string) error {
+	var opts options
+	set := flag.NewFlagSet("svg", flag.ExitOnError)
+	commonFlags(set, &opts)
+	set.StringVar(&opts.output, "out", "", "output svg file, stdout if empty")
+	set.IntVar(&opts.px_size, "px", 8, "size of each character in svg user units")
+	set.BoolVar(&opts.color, "color", true, "fill characters with the colors of the image")
+	set.StringVar(&opts.background_color, "bg", "#000000", "background `color` (#rrggbb)")
+	set.StringVar(&opts.font_stack, "font-family", "monospace", "font-family of the text, unused with -outlines")
+	set.BoolVar(&opts.outlines, "outlines", false, "draw glyph outlines as paths so the svg doesn't depend on installed fonts")
+	set.StringVar(&opts.font, "font", ascii_img.DefaultFontName, "with -outlines, bundled `font` name or TrueType path to trace glyphs from")
+	set.StringVar(&opts.aspect_arg, "aspect", "1", "width over height of each character cell, or \"font\" to use the font's proportions")
+
+	if err := parse(set, args, &opts); err != nil {
+		return err
+	}
+
+	background, err := parseHexColor(opts.background_color)
+	if err != nil {
+		return err
+	}
+
+	font, err := ascii_img.LoadFont(opts.font)
+	if err != nil {
+		return err
+	}
+
+	aspect, cell_width, err := cellShape(&opts, opts.font)
+	if err != nil {
+		return err
+	}
+
+	res, err := convert(&opts, ascii_img.WithRender(false), ascii_img.WithCellAspect(aspect))
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if opts.output != "" {
+		out, err = os.Create(opts.output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	return ascii_img.WriteSVG(out, res.Grid, ascii_img.SVGOptions{
+		CellSize:   opts.px_size,
+		CellWidth:  cell_width,
+		Color:      opts.color,
+		Background: background,
+		FontFamily: opts.font_stack,
+		Outlines:   opts.outlines,
+		Font:       font,
+	})
+}
+
+func writePDF(args []string) error {
+	var opts options
+	set := flag.NewFlagSet("pdf", flag.ExitOnError)
+	commonFlags(set, &opts)
+	set.StringVar(&opts.output, "out", "out.pdf", "output pdf file")
+	set.IntVar(&opts.px_size, "px", 8, "size in pixels of each character")
+	set.Float64Var(&opts.dpi, "dpi", 300, "printed resolution, a character is px/dpi inches wide")
+	set.StringVar(&opts.page, "page", "a4", "page size: a4, a3, a2, letter, tabloid (add -landscape) or WxH in points")
+	set.Float64Var(&opts.margin, "margin", 36, "margin around the printed area in points")
+	set.BoolVar(&opts.crop_marks, "crop-marks", false, "draw crop marks around the printed area of every page")
+	set.BoolVar(&opts.color, "color", true, "color characters with the colors of the image")
+	set.StringVar(&opts.background_color, "bg", "#000000", "background `color` (#rrggbb)")
+	set.StringVar(&opts.font, "font", ascii_img.DefaultFontName, "bundled `font` name or TrueType path to embed")
+	set.StringVar(&opts.aspect_arg, "aspect", "1", "width over height of each character cell, or \"font\" to use the font's proportions")
+
+	if err := parse(set, args, &opts); err != nil {
+		return err
+	}
+
+	background, err := parseHexColor(opts.background_color)
+	if err != nil {
+		return err
+	}
+
+	page, err := ascii_img.ParsePageSize(opts.page)
+	if err != nil {
+		return err
+	}
+
+	margin := opts.margin
+	if margin == 0 {
+		margin = -1 // no margin, 0 means the default in PDFOptions
+	}
+
+	aspect, cell_width, err := cellShape(&opts, opts.font)
+	if err != nil {
+		return err
+	}
+
+	res, err := convert(&opts, ascii_img.WithRender(false), ascii_img.WithCellAspect(aspect))
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(opts.output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	err = ascii_img.WritePDF(out, res.Grid, ascii_img.PDFOptions{
+		Page:       page,
+		CellSize:   opts.px_size,
+		CellWidth:  cell_width,
+		DPI:        opts.dpi,
+		Margin:     margin,
+		Color:      opts.color,
+		Background: background,
+		Font:       opts.font,
+		CropMarks:  opts.crop_marks,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Created " + opts.output)
+
+	return nil
+}
+
+// parses #rrggbb (the # is optional)
+func parseHexColor(s string) (color.RGBA, error) {
+	var c color.RGBA
+	hex := strings.TrimPrefix(s, "#")
+
+	if len(hex) != 6 {
+		return c, fmt.Errorf("invalid color %q, use #rrggbb", s)
+	}
+
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q, use #rrggbb", s)
+	}
+	c.A = 255
+
+	return c, nil
+}
+
+// parses a positive number, or "font" which maps to 0 (measured from the font, see ascii_img.WithCellAspect)
+func parseAspect(s string) (float64, error) {
+	if strings.EqualFold(s, "font") {
+		return 0, nil
+	}
+
+	aspect, err := strconv.ParseFloat(s, 64)
+	if err != nil || aspect <= 0 {
+		return 0, fmt.Errorf("invalid -aspect %q, use a positive number or \"font\"", s)
+	}
+
+	return aspect, nil
+}
+
+// reads -kernel-x/-kernel-y when given, otherwise the built-in -gradient operator
+func parseGradient(opts *options) (*transforms.GradientOperator, error) {
+	if opts.kernel_x == "" && opts.kernel_y == "" {
+		return transforms.ParseGradientOperator(opts.gradient_arg)
+	}
+
+	if opts.kernel_x == "" || opts.kernel_y == "" {
+		return nil, fmt.Errorf("-kernel-x and -kernel-y have to be given together")
+	}
+
+	gx, err := transforms.ParseKernel(opts.kernel_x)
+	if err != nil {
+		return nil, fmt.Errorf("-kernel-x: %w", err)
+	}
+	gy, err := transforms.ParseKernel(opts.kernel_y)
+	if err != nil {
+		return nil, fmt.Errorf("-kernel-y: %w", err)
+	}
+
+	return transforms.NewGradientOperator("custom", gx, gy)
+}
+
+// reads -aspect for the exporters, "font" is measured in font_name (the default font if empty). Returns the aspect to sample with and the cell width it draws.
+func cellShape(opts *options, font_name string) (aspect float64, cell_width int, err error) {
+	aspect, err = parseAspect(opts.aspect_arg)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if aspect == 0 {
+		if font_name == "" {
+			font_name = ascii_img.DefaultFontName
+		}
+		font, err := ascii_img.LoadFont(font_name)
+		if err != nil {
+			return 0, 0, err
+		}
+		aspect = ascii_img.GlyphAspect(font, opts.px_size)
+	}
+
+	return aspect, max(1, int(math.Round(float64(opts.px_size)*aspect))), nil
+}
+
+// reads -shape-grid and loads the font glyphs are matched in (-font, or the default font for commands without one)
+func parseShape(opts *options) error {
+	x, y, ok := strings.Cut(strings.ToLower(opts.shape_grid), "x")
+	gx, err_x := strconv.Atoi(x)
+	gy, err_y := strconv.Atoi(y)
+	if !ok || err_x != nil || err_y != nil || gx < 1 || gy < 1 || gx*gy > 12 {
+		return fmt.Errorf("invalid -shape-grid %q, use COLSxROWS with at most 12 regions, like 2x3", opts.shape_grid)
+	}
+	opts.shape_x, opts.shape_y = gx, gy
+
+	name := opts.font
+	if name == "" {
+		name = ascii_img.DefaultFontName
+	}
+	font, err := ascii_img.LoadFont(name)
+	if err != nil {
+		return err
+	}
+	opts.shape_font = font
+
+	return nil
+}
+
+// edge stage picked by -edges, -gradient, -blur1/-blur2, -dog-offset, -edge-cutoff, the -canny flags and -tensor-blur/-min-coherence
+func (opts *options) edgeOptions() transforms.EdgeOptions {
+	edges := transforms.DefaultEdgeOptions()
+	edges.Detector = opts.edge_detector
+	edges.Blur1, edges.Blur2 = opts.blur_1, opts.blur_2
+	edges.DoGOffset = opts.dog_offset
+	edges.Threshold = opts.edge_cutoff
+	edges.Operator = opts.gradient
+	edges.TensorBlur, edges.MinCoherence = opts.tensor_blur, opts.min_coherence
+	edges.CannyBlur = opts.canny_blur
+	edges.CannyLow, edges.CannyHigh = opts.canny_low, opts.canny_high
+
+	return edges
+}
+
+// grid size picked by -sample, -cols, -rows or -term
+func (opts *options) size() ascii_img.Size {
+	switch {
+	case opts.terminal:
+		return ascii_img.FitTerminal()
+	case opts.columns > 0 && opts.rows > 0:
+		return ascii_img.FitWithin(opts.columns, opts.rows)
+	case opts.columns > 0:
+		return ascii_img.ByColumns(opts.columns)
+	case opts.rows > 0:
+		return ascii_img.ByRows(opts.rows)
+	}
+
+	return ascii_img.BySample(float64(opts.sample_size))
+}
+
+// opens opts.input and runs it through a Converter built from opts and extra
+func convert(opts *options, extra ...ascii_img.Option) (*ascii_img.Result, error) {
+	file, err := os.Open(opts.input)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	img, err := ascii_img.OpenImg(file)
+	if err != nil {
+		return nil, err
+	}
+
+	converter_opts := append([]ascii_img.Option{
+		ascii_img.WithSize(opts.size()),
+		ascii_img.WithResampler(opts.resampler),
+		filters[opts.filter].make(opts),
+		ascii_img.WithRamp(opts.ramp),
+	}, extra...)
+
+	res, err := ascii_img.NewConverter(converter_opts...).Convert(context.Background(), img)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.verbose {
+		t := res.Timings
+		fmt.Fprintf(os.Stderr, "sample: %s, filter: %s, render: %s, total: %s\n", t.Sample, t.Filter, t.Render, t.Total)
+	}
+
+	return res, nil
+}
+
+func listFilters() {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Printf("%-8s %s\n", name, filters[name].description)
+	}
+}
+
+func listRamps() {
+	for _, name := range transforms.RampNames() {
+		ramp, _ := transforms.NamedRamp(name)
+		fmt.Printf("%-12s %q\n", name, ramp.String())
+	}
+}
+
+func listFonts() {
+	for _, name := range ascii_img.BundledFonts() {
+		fmt.Println(name)
+	}
+}
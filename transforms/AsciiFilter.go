@@ -1,26 +1,10 @@
 package transforms
 
-func NoEdgesFilter(arr [][]Pixel) {
-	mapping := map[int]rune{
-		0: ' ',
-		1: '.',
-		2: ':',
-		3: 'c',
-		4: 'o',
-		5: 'C',
-		6: 'O',
-		7: '0',
-		8: '@',
-		9: '■',
-	}
-
-	LuminFilter(arr, mapping)
-
-	sobel := SobelFilter(arr, true)
-
-	for i := range len(sobel) {
-		for j := range len(sobel[i]) {
-			char := sobel[i][j].Character
+// writes every edge character of sobel (anything but ' ') over arr's characters
+func overlayEdges(arr [][]Pixel, sobel *Plane) {
+	for i := range min(len(arr), sobel.Height) {
+		for j := range min(len(arr[i]), sobel.Width) {
+			char := sobel.Glyph[sobel.Index(j, i)]
 			if char != ' ' {
 				arr[i][j].Character = char
 			}
@@ -28,60 +12,53 @@ func NoEdgesFilter(arr [][]Pixel) {
 	}
 }
 
-func NaiveAsciiFilter(arr [][]Pixel) {
-	mapping := map[int]rune{
-		0: ' ',
-		1: '.',
-		2: ':',
-		3: 'c',
-		4: 'o',
-		5: 'C',
-		6: 'O',
-		7: '0',
-		8: '@',
-		9: '■',
-	}
-
-	LuminFilter(arr, mapping)
+func NoEdgesFilter(arr [][]Pixel, ramp Ramp) error {
+	return NoEdgesFilterEdges(arr, ramp, DefaultEdgeOptions())
+}
 
-	sobel := SobelFilter(arr, true)
+// NoEdgesFilter with the edges cut off and oriented as opts says, see NaiveAsciiFilterEdges
+func NoEdgesFilterEdges(arr [][]Pixel, ramp Ramp, opts EdgeOptions) error {
+	return NaiveAsciiFilterEdges(arr, ramp, opts)
+}
 
-	for i := range len(sobel) {
-		for j := range len(sobel[i]) {
-			char := sobel[i][j].Character
-			if char != ' ' {
-				arr[i][j].Character = char
-			}
-		}
-	}
+func NaiveAsciiFilter(arr [][]Pixel, ramp Ramp) error {
+	return NaiveAsciiFilterEdges(arr, ramp, DefaultEdgeOptions())
 }
 
-func AsciiFilter(arr [][]Pixel, blur_1 int, blur_2 int) {
-	mapping := map[int]rune{
-		0: ' ',
-		1: '.',
-		2: ':',
-		3: 'c',
-		4: 'o',
-		5: 'C',
-		6: 'O',
-		7: '0',
-		8: '@',
-		9: '■',
+/*
+NaiveAsciiFilter with the edges taken by opts.Operator and cut off by opts.Threshold, straight off the image. opts.TensorBlur and
+MinCoherence apply, the detector and DoG blurs don't.
+*/
+func NaiveAsciiFilterEdges(arr [][]Pixel, ramp Ramp, opts EdgeOptions) error {
+	LuminFilter(arr, ramp)
+
+	edges, err := opts.gradientEdges(PlaneFromPixels(arr))
+	if err != nil {
+		return err
 	}
 
-	LuminFilter(arr, mapping)
+	overlayEdges(arr, edges)
 
-	edged := DoG(arr, blur_1, blur_2)
+	return nil
+}
 
-	sobel := SobelFilter(edged, true)
+func AsciiFilter(arr [][]Pixel, ramp Ramp, blur_1 int, blur_2 int) error {
+	opts := DefaultEdgeOptions()
+	opts.Blur1, opts.Blur2 = blur_1, blur_2
 
-	for i := range len(sobel) {
-		for j := range len(sobel[i]) {
-			char := sobel[i][j].Character
-			if char != ' ' {
-				arr[i][j].Character = char
-			}
-		}
+	return AsciiFilterEdges(arr, ramp, opts)
+}
+
+// AsciiFilter with the edges found by opts, e.g. Canny in place of DoG
+func AsciiFilterEdges(arr [][]Pixel, ramp Ramp, opts EdgeOptions) error {
+	LuminFilter(arr, ramp)
+
+	edges, err := opts.Edges(PlaneFromPixels(arr))
+	if err != nil {
+		return err
 	}
+
+	overlayEdges(arr, edges)
+
+	return nil
 }
@@ -0,0 +1,83 @@
+package transforms
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// a soft vertical step, its sobel magnitudes are 60 down the middle columns
+func softStep() [][]Pixel {
+	arr := make([][]Pixel, 8)
+	for i := range arr {
+		arr[i] = make([]Pixel, 8)
+		for j := range arr[i] {
+			v := uint8(0)
+			if j >= 4 {
+				v = 15
+			}
+			arr[i][j] = Pixel{R: v, G: v, B: v, A: 255}
+		}
+	}
+
+	return arr
+}
+
+func edgeCount(arr [][]Pixel) int {
+	count := 0
+	for i := range arr {
+		for j := range arr[i] {
+			if strings.ContainsRune(`|/-\`, arr[i][j].Character) {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+func TestNaiveAsciiFilterEdges(t *testing.T) {
+	ramp := Ramp(" .:")
+
+	// the default cutoff of 100 misses the soft step
+	arr := softStep()
+	if err := NaiveAsciiFilter(arr, ramp); err != nil {
+		t.Fatal(err)
+	}
+	if got := edgeCount(arr); got != 0 {
+		t.Errorf("NaiveAsciiFilter found %v edges in a soft step, want none", got)
+	}
+
+	defaults := softStep()
+	if err := NaiveAsciiFilterEdges(defaults, ramp, DefaultEdgeOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(arr, defaults) {
+		t.Error("NaiveAsciiFilterEdges with DefaultEdgeOptions differs from NaiveAsciiFilter")
+	}
+
+	for _, threshold := range []Threshold{FixedThreshold(30), OtsuThreshold(), TopPercent(40)} {
+		opts := DefaultEdgeOptions()
+		opts.Threshold = threshold
+
+		for name, filter := range map[string]func([][]Pixel, Ramp, EdgeOptions) error{"naive": NaiveAsciiFilterEdges, "noedges": NoEdgesFilterEdges} {
+			arr := softStep()
+			if err := filter(arr, ramp, opts); err != nil {
+				t.Fatal(err)
+			}
+			// the two columns either side of the step, rows 1-6
+			if got := edgeCount(arr); got != 12 {
+				t.Errorf("%s with cutoff %v found %v edges, want 12", name, threshold, got)
+			}
+		}
+	}
+
+	opts := DefaultEdgeOptions()
+	opts.TensorBlur = 4
+	for name, filter := range map[string]func([][]Pixel, Ramp, EdgeOptions) error{"naive": NaiveAsciiFilterEdges, "noedges": NoEdgesFilterEdges} {
+		if err := filter(softStep(), ramp, opts); !errors.Is(err, ErrInvalidKernel) {
+			t.Errorf("%s with an even tensor blur: got %v, want ErrInvalidKernel", name, err)
+		}
+	}
+}
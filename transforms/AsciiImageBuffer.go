@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 
 	"github.com/golang/freetype"
 	"golang.org/x/image/math/fixed"
@@ -12,13 +13,14 @@ import (
 type AsciiImageBuffer struct {
 	x, y          int
 	width, height int
-	letter_size   int // a letter will take up a letter_size x letter_size amount of space (i.e. 4x4 space for each character)
+	letter_width  int // a letter will take up a letter_width x letter_height amount of space (i.e. 4x8 space for each character)
+	letter_height int
 	img           *image.RGBA
 }
 
-// Initializes a new AsciiImageBuffer
-func InitializeBuffer(x int, y int, width int, height int, letter_size int, img *image.RGBA) (buffer *AsciiImageBuffer) {
-	return &AsciiImageBuffer{x, y, width, height, letter_size, img}
+// Initializes a new AsciiImageBuffer. y is the baseline of the first row, usually letter_height.
+func InitializeBuffer(x int, y int, width int, height int, letter_width int, letter_height int, img *image.RGBA) (buffer *AsciiImageBuffer) {
+	return &AsciiImageBuffer{x, y, width, height, letter_width, letter_height, img}
 }
 
 /* Writes rune to the Context provided. AsciiImageBuffer keeps track of the current position, does wrapping for you.
@@ -26,21 +28,22 @@ func InitializeBuffer(x int, y int, width int, height int, letter_size int, img
 func (buffer *AsciiImageBuffer) WriteRune(context *freetype.Context, c color.Color, r rune, to_color bool) error {
 	if buffer.x >= buffer.width {
 		buffer.x = 0
-		buffer.y += buffer.letter_size
+		buffer.y += buffer.letter_height
 	}
 
-	if buffer.y >= buffer.height {
-		return fmt.Errorf("draw string overflow, y height is %v", buffer.y)
+	// y is the baseline, i.e. the bottom of the current row
+	if buffer.y > buffer.height {
+		return fmt.Errorf("%w: y height is %v", ErrBufferOverflow, buffer.y)
 	}
 
 	if r == 0 {
-		for i := buffer.x; i < buffer.x+buffer.letter_size; i++ {
+		for i := buffer.x; i < buffer.x+buffer.letter_width; i++ {
 			// we draw from bottom left, so translate up one letter size
-			for j := buffer.y - buffer.letter_size; j < buffer.y+buffer.letter_size; j++ {
+			for j := buffer.y - buffer.letter_height; j < buffer.y; j++ {
 				buffer.img.Set(i, j, c)
 			}
 		}
-		buffer.x += buffer.letter_size
+		buffer.x += buffer.letter_width
 		return nil
 	}
 
@@ -54,20 +57,99 @@ func (buffer *AsciiImageBuffer) WriteRune(context *freetype.Context, c color.Col
 		return err
 	}
 
-	buffer.x += buffer.letter_size
+	buffer.x += buffer.letter_width
 
 	return nil
 }
 
-/* Writes array to the Context provided. AsciiImageBuffer keeps track of the current position, does wrapping for you. Provide true
+/* Writes array to the Context provided. AsciiImageBuffer keeps track of the current position, does wrapping for you. Provide true to draw each character in its pixel's color. Returns the first error (e.g. ErrBufferOverflow).
  */
-func (buffer *AsciiImageBuffer) WriteArray(context *freetype.Context, arr [][]Pixel, to_color bool) {
+func (buffer *AsciiImageBuffer) WriteArray(context *freetype.Context, arr [][]Pixel, to_color bool) error {
 	for i := range len(arr) {
 		for j := range len(arr[i]) {
 			cur := &arr[i][j]
 			if err := buffer.WriteRune(context, color.RGBA{cur.R, cur.G, cur.B, cur.A}, cur.Character, to_color); err != nil {
-				break
+				return fmt.Errorf("writing (%v, %v): %w", j, i, err)
 			}
 		}
 	}
+
+	return nil
+}
+
+/*
+Same as WriteArray, drawing with atlas's pre-rasterized glyphs instead of a freetype.Context. Every row of arr is one row of the buffer,
+starting at the current position. With parallel, every goroutine owns a strip of the image and draws each cell reaching into it (see
+GlyphAtlas.Reach) clipped to the strip, in the same order as drawing serially. Glyphs spilling into a neighbouring row are then never
+drawn by two goroutines at once, and overlapping glyphs and solid cells stack up the same way they do serially.
+*/
+func (buffer *AsciiImageBuffer) WriteArrayAtlas(atlas *GlyphAtlas, arr [][]Pixel, to_color bool, parallel bool) error {
+	if len(arr) == 0 {
+		return nil
+	}
+
+	if buffer.x >= buffer.width {
+		buffer.x = 0
+		buffer.y += buffer.letter_height
+	}
+
+	// y is the baseline, i.e. the bottom of the current row
+	if last := buffer.y + (len(arr)-1)*buffer.letter_height; last > buffer.height {
+		return fmt.Errorf("%w: y height is %v", ErrBufferOverflow, last)
+	}
+
+	origin_x, origin_y := buffer.x, buffer.y
+	bounds := buffer.img.Bounds()
+
+	// draws rows start to end clipped to clip, row by row and left to right like WriteArray
+	rows := func(start int, end int, clip image.Rectangle) {
+		// one source per goroutine, pointing at a color updated for every cell so nothing is allocated per cell
+		var c color.RGBA
+		src := &image.Uniform{C: &c}
+
+		for i := start; i < end; i++ {
+			y := origin_y + i*buffer.letter_height
+			for j := range len(arr[i]) {
+				cur := &arr[i][j]
+				x := origin_x + j*buffer.letter_width
+				c = color.RGBA{cur.R, cur.G, cur.B, cur.A}
+
+				if cur.Character == 0 {
+					cell := image.Rect(x, y-buffer.letter_height, x+buffer.letter_width, y).Intersect(clip)
+					draw.Draw(buffer.img, cell, src, image.Point{}, draw.Src)
+					continue
+				}
+
+				if !to_color {
+					c = color.RGBA{255, 255, 255, 255}
+				}
+				atlas.DrawRune(buffer.img, clip, x, y, cur.Character, src)
+			}
+		}
+	}
+
+	if !parallel {
+		rows(0, len(arr), bounds)
+	} else {
+		// rows of cells a glyph can reach past its own, up or down
+		reach_rows := atlas.Reach()/max(1, buffer.letter_height) + 1
+
+		// the strip of rows start to end runs from the top of row start to the top of row end, the first and last strips out to the image's edges
+		ParallelRows(len(arr), func(start int, end int) {
+			strip := bounds
+			if start > 0 {
+				strip.Min.Y = origin_y + (start-1)*buffer.letter_height
+			}
+			if end < len(arr) {
+				strip.Max.Y = origin_y + (end-1)*buffer.letter_height
+			}
+
+			rows(max(0, start-reach_rows), min(len(arr), end+reach_rows), strip.Intersect(bounds))
+		})
+	}
+
+	buffer.x = 0
+	buffer.y = origin_y + len(arr)*buffer.letter_height
+
+	return nil
 }
@@ -0,0 +1,90 @@
+package transforms
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"testing"
+
+	fonts "github.com/RohanPalivela/ascii_image_manip/Fonts"
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+)
+
+func bundledFont(t *testing.T, name string) *truetype.Font {
+	font_bytes, ok := fonts.Bytes(name)
+	if !ok {
+		t.Fatalf("no bundled %s font", name)
+	}
+	font, err := freetype.ParseFont(font_bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return font
+}
+
+// draws arr serially, then in parallel a few times, failing if any parallel image differs from the serial one
+func checkParallelAtlas(t *testing.T, atlas *GlyphAtlas, arr [][]Pixel, cell int) {
+	draw := func(parallel bool) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, len(arr[0])*cell, len(arr)*cell))
+		buffer := InitializeBuffer(0, cell, img.Bounds().Dx(), img.Bounds().Dy(), cell, cell, img)
+		if err := buffer.WriteArrayAtlas(atlas, arr, true, parallel); err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	SetWorkers(8)
+	defer SetWorkers(0)
+
+	serial := draw(false)
+	// run with -race: overlapping glyphs drawn by two goroutines at once are a data race
+	for range 5 {
+		if !bytes.Equal(draw(true).Pix, serial.Pix) {
+			t.Fatal("drawing rows in parallel differs from drawing them one by one")
+		}
+	}
+}
+
+func TestWriteArrayAtlasSmallRows(t *testing.T) {
+	// glyphs 24px tall in 3px rows reach 16 rows up and down
+	atlas := NewGlyphAtlas(bundledFont(t, "MC"), 24, StandardRamp())
+
+	arr := make([][]Pixel, 60)
+	for i := range arr {
+		arr[i] = make([]Pixel, 12)
+		for j := range arr[i] {
+			arr[i][j] = Pixel{R: 255, G: 255, B: 255, A: 255, Character: '@'}
+		}
+	}
+
+	checkParallelAtlas(t, atlas, arr, 3)
+}
+
+func TestWriteArrayAtlasMixedCells(t *testing.T) {
+	glyphs := []rune("@#%&WMgjy")
+
+	// solid cells between glyphs of every color, so glyphs spilling into the rows around them overlap other glyphs and solid cells
+	arr := make([][]Pixel, 80)
+	for i := range arr {
+		arr[i] = make([]Pixel, 16)
+		for j := range arr[i] {
+			p := Pixel{R: uint8(40 * i), G: uint8(70 * j), B: uint8(25 * (i + j)), A: 255}
+			if (3*i+j)%4 != 0 {
+				p.Character = glyphs[(i+2*j)%len(glyphs)]
+			}
+			arr[i][j] = p
+		}
+	}
+
+	for _, name := range []string{"MC", "Mont"} {
+		font := bundledFont(t, name)
+		for _, sizes := range [][2]int{{8, 8}, {20, 4}, {12, 2}} {
+			px_size, cell := sizes[0], sizes[1]
+			t.Run(fmt.Sprintf("%s %vpx in %vpx cells", name, px_size, cell), func(t *testing.T) {
+				checkParallelAtlas(t, NewGlyphAtlas(font, float64(px_size), nil), arr, cell)
+			})
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package transforms
+
+import (
+	"strings"
+	"testing"
+)
+
+// white where inside(x, y), black elsewhere
+func shapePlane(width int, height int, inside func(x int, y int) bool) *Plane {
+	plane := NewPlane(width, height)
+	for y := range height {
+		for x := range width {
+			p := Pixel{A: 255}
+			if inside(x, y) {
+				p.R, p.G, p.B = 255, 255, 255
+			}
+			plane.SetPixel(x, y, &p)
+		}
+	}
+
+	return plane
+}
+
+func edgeString(edges *EdgeMap) string {
+	var sb strings.Builder
+	for y := range edges.Height {
+		for x := range edges.Width {
+			if edges.Mask[edges.Index(x, y)] {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
+
+// pixels of the line across an edge (along its gradient) numbered k, inside the 21x21 planes the thinness tests use
+type acrossLine func(k int) [][2]int
+
+func TestCannyThin(t *testing.T) {
+	tests := []struct {
+		name   string
+		inside func(x int, y int) bool
+		across acrossLine
+		glyphs string // directions the edge pixels may have
+	}{
+		{"vertical step", func(x, y int) bool { return x >= 10 }, rowLine, "|"},
+		{"horizontal step", func(x, y int) bool { return y >= 10 }, columnLine, "-"},
+		{"diagonal", func(x, y int) bool { return x > y }, antiDiagonalLine, "\\"},
+		{"anti diagonal", func(x, y int) bool { return x+y > 20 }, diagonalLine, "/"},
+	}
+
+	for _, test := range tests {
+		for _, kernel_size := range []int{1, 3, 5} {
+			img := shapePlane(21, 21, test.inside)
+			edges, err := Canny(img, kernel_size, 50, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// away from the border, the edge is one pixel wide measured across it
+			for k := 6; k < 15; k++ {
+				count := 0
+				for _, p := range test.across(k) {
+					index := edges.Index(p[0], p[1])
+					if !edges.Mask[index] {
+						continue
+					}
+					count++
+					if glyph := EdgeGlyph(float64(edges.Angle[index])); !strings.ContainsRune(test.glyphs, glyph) {
+						t.Errorf("%s, kernel %v: edge pixel %v points %q, want one of %q", test.name, kernel_size, p, glyph, test.glyphs)
+					}
+				}
+				if count != 1 {
+					t.Fatalf("%s, kernel %v: %v edge pixels across line %v, want 1:\n%s", test.name, kernel_size, count, k, edgeString(edges))
+				}
+			}
+		}
+	}
+}
+
+// pixels (x, y) of a 21x21 plane, 3 or more away from the border, with f(x, y) == k
+func linePixels(k int, f func(x int, y int) int) [][2]int {
+	var pixels [][2]int
+	for y := 3; y < 18; y++ {
+		for x := 3; x < 18; x++ {
+			if f(x, y) == k {
+				pixels = append(pixels, [2]int{x, y})
+			}
+		}
+	}
+
+	return pixels
+}
+
+func rowLine(k int) [][2]int    { return linePixels(k, func(x, y int) int { return y }) }
+func columnLine(k int) [][2]int { return linePixels(k, func(x, y int) int { return x }) }
+
+// crosses the x == y diagonal
+func antiDiagonalLine(k int) [][2]int { return linePixels(k+6, func(x, y int) int { return x + y }) }
+
+// crosses the x + y == 20 anti diagonal
+func diagonalLine(k int) [][2]int { return linePixels(k-10, func(x, y int) int { return x - y }) }
+
+func TestCannyFlat(t *testing.T) {
+	edges, err := Canny(shapePlane(16, 16, func(x, y int) bool { return true }), 3, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(edgeString(edges), "#") {
+		t.Errorf("flat image has edges:\n%s", edgeString(edges))
+	}
+}
+
+func TestHysteresis(t *testing.T) {
+	const low, high = 40, 100
+
+	tests := []struct {
+		name   string
+		thin   map[[2]int]float32
+		wanted map[[2]int]bool
+	}{
+		{
+			"weak next to strong survives",
+			map[[2]int]float32{{2, 2}: 200, {3, 2}: 50},
+			map[[2]int]bool{{2, 2}: true, {3, 2}: true},
+		},
+		{
+			"weak chain diagonal to strong survives",
+			map[[2]int]float32{{2, 2}: 200, {3, 3}: 50, {4, 4}: 60, {5, 4}: 45},
+			map[[2]int]bool{{2, 2}: true, {3, 3}: true, {4, 4}: true, {5, 4}: true},
+		},
+		{
+			"isolated weak is dropped",
+			map[[2]int]float32{{2, 2}: 200, {6, 6}: 90},
+			map[[2]int]bool{{2, 2}: true, {6, 6}: false},
+		},
+		{
+			"weak two pixels from strong is dropped",
+			map[[2]int]float32{{2, 2}: 200, {4, 2}: 90},
+			map[[2]int]bool{{2, 2}: true, {4, 2}: false},
+		},
+		{
+			"below low breaks the chain",
+			map[[2]int]float32{{2, 2}: 200, {3, 2}: 30, {4, 2}: 90},
+			map[[2]int]bool{{2, 2}: true, {3, 2}: false, {4, 2}: false},
+		},
+		{
+			"strong alone survives",
+			map[[2]int]float32{{7, 7}: 100},
+			map[[2]int]bool{{7, 7}: true},
+		},
+	}
+
+	for _, test := range tests {
+		edges := NewEdgeMap(8, 8)
+		thin := make([]float32, 8*8)
+		for p, magnitude := range test.thin {
+			thin[edges.Index(p[0], p[1])] = magnitude
+		}
+
+		hysteresis(edges, thin, low, high)
+
+		for y := range 8 {
+			for x := range 8 {
+				if got, want := edges.Mask[edges.Index(x, y)], test.wanted[[2]int{x, y}]; got != want {
+					t.Errorf("%s: (%v, %v) is an edge: %v, want %v:\n%s", test.name, x, y, got, want, edgeString(edges))
+				}
+			}
+		}
+	}
+}
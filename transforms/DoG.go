@@ -1,88 +1,110 @@
 package transforms
 
 import (
+	"errors"
 	"math"
 	"sync"
 )
 
 // blur_rad_1 < blur_rad_2
-func DoG(img [][]Pixel, blur_rad_1 int, blur_rad_2 int) [][]Pixel {
+func DoG(img [][]Pixel, blur_rad_1 int, blur_rad_2 int) ([][]Pixel, error) {
+	result, err := DoGPlane(PlaneFromPixels(img), blur_rad_1, blur_rad_2)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Pixels(), nil
+}
+
+// Same as DoG on a Plane
+func DoGPlane(img *Plane, blur_rad_1 int, blur_rad_2 int) (*Plane, error) {
+	return DoGPlaneOffset(img, blur_rad_1, blur_rad_2, 15)
+}
+
+// DoGPlane with offset (0-255) added to every response before it's clamped to 255
+func DoGPlaneOffset(img *Plane, blur_rad_1 int, blur_rad_2 int, offset float64) (*Plane, error) {
 	var group sync.WaitGroup
-	var blur1, blur2 [][]Pixel
+	var blur1, blur2 *Plane
+	var err1, err2 error
 
 	group.Go(func() {
-		blur1 = GaussianBlur1D(img, blur_rad_1)
+		blur1, err1 = GaussianBlur1DPlane(img, blur_rad_1)
 	})
 
 	group.Go(func() {
-		blur2 = GaussianBlur1D(img, blur_rad_2)
+		blur2, err2 = GaussianBlur1DPlane(img, blur_rad_2)
 	})
 
 	group.Wait()
 
-	result := make([][]Pixel, len(blur1))
-	for i := range len(blur1) {
-		result[i] = make([]Pixel, len(blur1[i]))
+	if err := errors.Join(err1, err2); err != nil {
+		return nil, err
 	}
 
-	for i := range len(blur1) {
-		for j := range len(blur1[i]) {
-			pix1 := &blur1[i][j]
-			pix2 := &blur2[i][j]
-			pix1Lum := Luminance(pix1) / 255.0
-			pix2Lum := Luminance(pix2) / 255.0
-
-			finRes := max(0, math.Abs(pix1Lum-pix2Lum)) // 0-1 range
-
-			pix_val := min(255, 15+(uint8)(finRes*255))
-			result[i][j] = Pixel{
-				R: pix_val,
-				G: pix_val,
-				B: pix_val,
-				A: 255,
+	result := NewPlane(img.Width, img.Height)
+
+	ParallelRows(img.Height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range img.Width {
+				pix1Lum := float64(blur1.Lum[blur1.Index(j, i)]) / 255.0
+				pix2Lum := float64(blur2.Lum[blur2.Index(j, i)]) / 255.0
+
+				finRes := max(0, math.Abs(pix1Lum-pix2Lum)) // 0-1 range
+
+				pix_val := uint8(min(255, offset+finRes*255))
+				result.setGray(result.Index(j, i), pix_val)
 			}
 		}
+	})
+
+	return result, nil
+}
+
+func XDoG(img [][]Pixel) ([][]Pixel, error) {
+	result, err := XDoGPlane(PlaneFromPixels(img))
+	if err != nil {
+		return nil, err
 	}
 
-	return result
+	return result.Pixels(), nil
 }
 
-func XDoG(img [][]Pixel) [][]Pixel {
-	blur1 := GaussianBlur1D(img, 7)
-	blur2 := GaussianBlur1D(img, 11)
+// Same as XDoG on a Plane
+func XDoGPlane(img *Plane) (*Plane, error) {
+	blur1, err := GaussianBlur1DPlane(img, 7)
+	if err != nil {
+		return nil, err
+	}
 
-	result := make([][]Pixel, len(blur1))
-	for i := range len(blur1) {
-		result[i] = make([]Pixel, len(blur1[i]))
+	blur2, err := GaussianBlur1DPlane(img, 11)
+	if err != nil {
+		return nil, err
 	}
 
+	result := NewPlane(img.Width, img.Height)
+
 	tau := 0.96     // blur constant
 	epsilon := 0.05 // threshold value
 	phi := 40.0     // edge hardness
-	for i := range len(blur1) {
-		for j := range len(blur1[i]) {
-			pix1 := &blur1[i][j]
-			pix2 := &blur2[i][j]
-			pix1Lum := Luminance(pix1) / 255.0
-			pix2Lum := Luminance(pix2) / 255.0
-
-			finRes := max(0, pix1Lum-tau*pix2Lum) // 0-1 range
-
-			if finRes >= epsilon {
-				finRes = 1
-			} else {
-				finRes = 0.5 * (1 + math.Tanh(phi*(finRes-epsilon)))
-			}
-
-			pix_val := (uint8)(finRes * 255)
-			result[i][j] = Pixel{
-				R: pix_val,
-				G: pix_val,
-				B: pix_val,
-				A: 255,
+	ParallelRows(img.Height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range img.Width {
+				pix1Lum := float64(blur1.Lum[blur1.Index(j, i)]) / 255.0
+				pix2Lum := float64(blur2.Lum[blur2.Index(j, i)]) / 255.0
+
+				finRes := max(0, pix1Lum-tau*pix2Lum) // 0-1 range
+
+				if finRes >= epsilon {
+					finRes = 1
+				} else {
+					finRes = 0.5 * (1 + math.Tanh(phi*(finRes-epsilon)))
+				}
+
+				pix_val := (uint8)(finRes * 255)
+				result.setGray(result.Index(j, i), pix_val)
 			}
 		}
-	}
+	})
 
-	return result
+	return result, nil
 }
@@ -0,0 +1,42 @@
+package transforms
+
+import "testing"
+
+func TestDoGPlaneStrongResponse(t *testing.T) {
+	// a lone white pixel on black, the 1 blur keeps it and the 15 blur spreads it out, so it responds at nearly 255
+	img := stepPlane(15, 15, 15)
+	img.SetPixel(7, 7, &Pixel{R: 255, G: 255, B: 255, A: 255})
+
+	for _, offset := range []float64{0, 15, 100} {
+		result, err := DoGPlaneOffset(img, 1, 15, offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := result.Pixel(7, 7).R; got < 230 {
+			t.Errorf("offset %v: strong response came out %v, want near 255", offset, got)
+		}
+		if got := result.Pixel(0, 0).R; got != uint8(offset) {
+			t.Errorf("offset %v: flat black corner came out %v, want the offset", offset, got)
+		}
+	}
+}
+
+func TestDoGPlaneDefaultOffset(t *testing.T) {
+	img := stepPlane(9, 9, 4)
+
+	plain, err := DoGPlane(img, 1, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err := DoGPlaneOffset(img, 1, 15, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range plain.Lum {
+		if plain.Lum[i] != offset.Lum[i] {
+			t.Fatalf("DoGPlane differs from DoGPlaneOffset(_, 15) at %v: %v and %v", i, plain.Lum[i], offset.Lum[i])
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package transforms
+
+import (
+	"fmt"
+	"strings"
+)
+
+// *****************
+// EDGE STAGE
+// *****************
+
+// Which detector finds the edges the composite filters draw
+type EdgeDetector int
+
+const (
+	EdgesDoG   EdgeDetector = iota // difference of gaussians then SobelPlane, thick edge bands
+	EdgesCanny                     // Canny, one pixel wide edges with hysteresis
+)
+
+var edge_detector_names = []string{"dog", "canny"}
+
+// Parses dog or canny
+func ParseEdgeDetector(s string) (EdgeDetector, error) {
+	for i, name := range edge_detector_names {
+		if strings.EqualFold(s, name) {
+			return EdgeDetector(i), nil
+		}
+	}
+
+	return EdgesDoG, fmt.Errorf("unknown edge detector %q, use %s", s, strings.Join(edge_detector_names, " or "))
+}
+
+func (detector EdgeDetector) String() string {
+	if detector < 0 || int(detector) >= len(edge_detector_names) {
+		return fmt.Sprintf("EdgeDetector(%d)", int(detector))
+	}
+
+	return edge_detector_names[detector]
+}
+
+// How AsciiFilterEdges and FullResAsciiFilterEdges find edges, start from DefaultEdgeOptions
+type EdgeOptions struct {
+	Detector EdgeDetector
+
+	// DoG blur kernel sizes, Blur1 < Blur2, both odd
+	Blur1, Blur2 int
+
+	// added to every DoG response (0-255) before the sobel pass, see DoGPlaneOffset
+	DoGOffset float64
+
+	// sobel magnitude cutoff after DoG, see Threshold
+	Threshold Threshold
+
+	// kernels the gradient is taken with by either detector, nil is SobelOperator()
+	Operator *GradientOperator
+
+	// Canny pre-blur kernel size (odd) and hysteresis thresholds, see Canny
+	CannyBlur           int
+	CannyLow, CannyHigh float64
+
+	/*
+		Kernel size (odd) of the structure tensor smoothing edge directions come from, see StructureTensor. 0 keeps the raw direction of
+		every pixel. With it, edge pixels less coherent than MinCoherence (0-1) are dropped.
+	*/
+	TensorBlur   int
+	MinCoherence float64
+}
+
+// DoG with the 1 and 15 blurs, offset of 15 and fixed cutoff of 100 AsciiFilter has always used, Canny settings ready for when Detector is switched
+func DefaultEdgeOptions() EdgeOptions {
+	return EdgeOptions{
+		Detector:  EdgesDoG,
+		Blur1:     1,
+		Blur2:     15,
+		DoGOffset: 15,
+		Threshold: FixedThreshold(100),
+		CannyBlur: 5,
+		CannyLow:  40,
+		CannyHigh: 100,
+	}
+}
+
+// Edges of img drawn like SobelPlane(_, true): edge pixels carry their direction glyph, the rest ' '
+func (opts EdgeOptions) Edges(img *Plane) (*Plane, error) {
+	if opts.Detector != EdgesCanny {
+		edged, err := DoGPlaneOffset(img, opts.Blur1, opts.Blur2, opts.DoGOffset)
+		if err != nil {
+			return nil, err
+		}
+
+		return opts.gradientEdges(edged)
+	}
+
+	op := opts.operator()
+	found, err := CannyOperator(img, op, opts.CannyBlur, opts.CannyLow, opts.CannyHigh)
+	if err != nil {
+		return nil, err
+	}
+
+	return opts.orient(found.Plane(), img, op)
+}
+
+// edges of img's own gradient above Threshold, no DoG or Canny
+func (opts EdgeOptions) gradientEdges(img *Plane) (*Plane, error) {
+	op := opts.operator()
+
+	return opts.orient(GradientPlane(img, op, opts.Threshold), img, op)
+}
+
+func (opts EdgeOptions) operator() *GradientOperator {
+	if opts.Operator == nil {
+		return SobelOperator()
+	}
+
+	return opts.Operator
+}
+
+// smooths the directions of edges, found on source, along contours when TensorBlur is set
+func (opts EdgeOptions) orient(edges *Plane, source *Plane, op *GradientOperator) (*Plane, error) {
+	if opts.TensorBlur > 0 {
+		orientation, err := StructureTensor(source, op, opts.TensorBlur)
+		if err != nil {
+			return nil, err
+		}
+
+		OrientEdges(edges, orientation, opts.MinCoherence)
+	}
+
+	return edges, nil
+}
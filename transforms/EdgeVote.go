@@ -0,0 +1,91 @@
+package transforms
+
+import "math"
+
+// *****************
+// FULL RESOLUTION EDGES
+// *****************
+
+// First source pixel and pixel count of cell i along an axis sampled every stride pixels (fractional strides round down), at least one pixel when upscaling
+func StrideSpan(i int, stride float64) (start int, count int) {
+	start = int(math.Floor(float64(i)*stride + 1e-9))
+	end := int(math.Floor(float64(i+1)*stride + 1e-9))
+
+	return start, max(1, end-start)
+}
+
+/*
+Overlays edges (SobelPlane output with characters, at the resolution arr was sampled from) onto arr. Cell (bx, by) covers the edge
+pixels from bx*stride_x to (bx+1)*stride_x and likewise for y. Its most common edge direction (ties going to the first of | / - \) is
+written when at least threshold (0-1) of the cell's pixels agree on it, otherwise the cell keeps its character, like the GPU
+implementation's per tile vote.
+*/
+func VoteEdges(arr [][]Pixel, edges *Plane, stride_x float64, stride_y float64, threshold float64) {
+	directions := [4]rune{'|', '/', '-', '\\'}
+
+	ParallelRows(len(arr), func(start int, end int) {
+		for by := start; by < end; by++ {
+			y0, sample_y := StrideSpan(by, stride_y)
+			y1 := min(y0+sample_y, edges.Height)
+			for bx := range len(arr[by]) {
+				x0, sample_x := StrideSpan(bx, stride_x)
+				x1 := min(x0+sample_x, edges.Width)
+				if x1 <= x0 || y1 <= y0 {
+					continue
+				}
+
+				var votes [4]int
+				for y := y0; y < y1; y++ {
+					for _, r := range edges.Glyph[edges.Index(x0, y):edges.Index(x1, y)] {
+						switch r {
+						case '|':
+							votes[0]++
+						case '/':
+							votes[1]++
+						case '-':
+							votes[2]++
+						case '\\':
+							votes[3]++
+						}
+					}
+				}
+
+				best := 0
+				for d := range votes {
+					if votes[d] > votes[best] {
+						best = d
+					}
+				}
+
+				if votes[best] > 0 && float64(votes[best]) >= threshold*float64((x1-x0)*(y1-y0)) {
+					arr[by][bx].Character = directions[best]
+				}
+			}
+		}
+	})
+}
+
+/*
+AsciiFilter with the DoG and Sobel passes run on full, the full resolution image arr was sampled from (every stride_x x stride_y
+pixels), instead of on arr itself. Edge directions are voted per cell, see VoteEdges, so fine lines survive the downsampling.
+*/
+func FullResAsciiFilter(arr [][]Pixel, full *Plane, ramp Ramp, blur_1 int, blur_2 int, stride_x float64, stride_y float64, threshold float64) error {
+	opts := DefaultEdgeOptions()
+	opts.Blur1, opts.Blur2 = blur_1, blur_2
+
+	return FullResAsciiFilterEdges(arr, full, ramp, opts, stride_x, stride_y, threshold)
+}
+
+// FullResAsciiFilter with the edges found by opts
+func FullResAsciiFilterEdges(arr [][]Pixel, full *Plane, ramp Ramp, opts EdgeOptions, stride_x float64, stride_y float64, threshold float64) error {
+	LuminFilter(arr, ramp)
+
+	edges, err := opts.Edges(full)
+	if err != nil {
+		return err
+	}
+
+	VoteEdges(arr, edges, stride_x, stride_y, threshold)
+
+	return nil
+}
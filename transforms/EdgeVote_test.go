@@ -0,0 +1,87 @@
+package transforms
+
+import "testing"
+
+func TestStrideSpan(t *testing.T) {
+	tests := []struct {
+		stride float64
+		spans  [][2]int // start and count of cells 0, 1, 2...
+	}{
+		{4, [][2]int{{0, 4}, {4, 4}, {8, 4}}},
+		{2.5, [][2]int{{0, 2}, {2, 3}, {5, 2}, {7, 3}}},
+		{1.5, [][2]int{{0, 1}, {1, 2}, {3, 1}, {4, 2}}},
+		{1.0 / 3, [][2]int{{0, 1}, {0, 1}, {0, 1}, {1, 1}}},
+		{0.5, [][2]int{{0, 1}, {0, 1}, {1, 1}, {1, 1}}},
+	}
+
+	for _, test := range tests {
+		for i, want := range test.spans {
+			if start, count := StrideSpan(i, test.stride); start != want[0] || count != want[1] {
+				t.Errorf("StrideSpan(%v, %v) = %v, %v, want %v, %v", i, test.stride, start, count, want[0], want[1])
+			}
+		}
+	}
+}
+
+// edges plane with the glyphs of rows, ' ' past the end of a row
+func glyphPlane(width int, height int, rows ...string) *Plane {
+	plane := NewPlane(width, height)
+	for i := range plane.Glyph {
+		plane.Glyph[i] = ' '
+	}
+	for y, row := range rows {
+		for x, r := range []rune(row) {
+			plane.Glyph[plane.Index(x, y)] = r
+		}
+	}
+
+	return plane
+}
+
+func TestVoteEdges(t *testing.T) {
+	tests := []struct {
+		name      string
+		edges     []string // one 4x4 cell
+		threshold float64
+		want      rune
+	}{
+		{"below threshold", []string{"|||"}, 0.25, '.'},
+		{"at threshold", []string{"||||"}, 0.25, '|'},
+		{"above threshold", []string{"||||", "|"}, 0.25, '|'},
+		{"majority", []string{"//-", "\\//"}, 0.25, '/'},
+		{"tie goes to the first direction", []string{"-\\-\\", "\\-"}, 0.1, '-'},
+		{"tie of | and /", []string{"/|/|"}, 0.1, '|'},
+		{"no edges at zero threshold", []string{}, 0, '.'},
+		{"every pixel", []string{"----", "----", "----", "----"}, 1, '-'},
+	}
+
+	for _, test := range tests {
+		arr := [][]Pixel{{{Character: '.'}}}
+		VoteEdges(arr, glyphPlane(4, 4, test.edges...), 4, 4, test.threshold)
+		if got := arr[0][0].Character; got != test.want {
+			t.Errorf("%s: cell became %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestVoteEdgesFractionalStride(t *testing.T) {
+	// a stride of 2.5 splits 5 columns into cells of 2 and 3, each voted over its own pixels
+	edges := glyphPlane(5, 2, "  ||/", "  ||/")
+	arr := [][]Pixel{{{Character: '.'}, {Character: '.'}}}
+
+	VoteEdges(arr, edges, 2.5, 2, 0.5)
+	if got := arr[0][0].Character; got != '.' {
+		t.Errorf("cell over columns 0-1 became %q, want '.'", got)
+	}
+	// 4 of its 6 pixels agree
+	if got := arr[0][1].Character; got != '|' {
+		t.Errorf("cell over columns 2-4 became %q, want '|'", got)
+	}
+
+	// 4 of 6 is short of 0.7
+	arr = [][]Pixel{{{Character: '.'}, {Character: '.'}}}
+	VoteEdges(arr, edges, 2.5, 2, 0.7)
+	if got := arr[0][1].Character; got != '.' {
+		t.Errorf("cell over columns 2-4 at threshold 0.7 became %q, want '.'", got)
+	}
+}
@@ -1,13 +1,23 @@
 package transforms
 
 import (
+	"fmt"
 	"math"
 )
 
-func GaussianBlur1D(arr [][]Pixel, kernel_size int) [][]Pixel {
-	newarr := blur(arr, kernel_size)
+// Blurs the array with two separable 1D passes of a kernel_size gaussian kernel. kernel_size must be odd and positive.
+func GaussianBlur1D(arr [][]Pixel, kernel_size int) ([][]Pixel, error) {
+	newplane, err := GaussianBlur1DPlane(PlaneFromPixels(arr), kernel_size)
+	if err != nil {
+		return nil, err
+	}
+
+	return newplane.Pixels(), nil
+}
 
-	return newarr
+// Same as GaussianBlur1D on a Plane. The result has no characters.
+func GaussianBlur1DPlane(img *Plane, kernel_size int) (*Plane, error) {
+	return blur(img, kernel_size)
 }
 
 func gaussianFunction1D(x float64, sigma float64) float64 {
@@ -18,9 +28,9 @@ func gaussianFunction1D(x float64, sigma float64) float64 {
 	return result
 }
 
-func gausKernel1D(kernel_size int) []float64 {
+func gausKernel1D(kernel_size int) ([]float64, error) {
 	if kernel_size%2 == 0 || kernel_size < 1 {
-		panic("Enter valid kernel_size")
+		return nil, fmt.Errorf("%w: %v, needs to be odd and positive", ErrInvalidKernel, kernel_size)
 	}
 
 	sigma := float64(kernel_size) / 6
@@ -37,76 +47,60 @@ func gausKernel1D(kernel_size int) []float64 {
 		kernel[i] /= sum
 	}
 
-	return kernel
+	return kernel, nil
 }
 
-func blur(arr [][]Pixel, kernel_size int) [][]Pixel {
-	kernel := gausKernel1D(kernel_size)
+func blur(img *Plane, kernel_size int) (*Plane, error) {
+	kernel, err := gausKernel1D(kernel_size)
+	if err != nil {
+		return nil, err
+	}
 	radius := kernel_size / 2
 
-	result := make([][]Pixel, len(arr))
-	tmp := make([][]Pixel, len(arr))
-
-	for i := range len(arr) {
-		result[i] = make([]Pixel, len(arr[i]))
-		tmp[i] = make([]Pixel, len(arr[i]))
-	}
+	width, height := img.Width, img.Height
+	result := NewPlane(width, height)
+	tmp := NewPlane(width, height)
 
 	// horizontal pass
-	for i := range len(arr) {
-		for j := range len(arr[i]) {
-			var r, g, b, a float64 = 0, 0, 0, 0
-			for k := -radius; k <= radius; k++ {
-				var pix Pixel
-				if j+k < 0 {
-					pix = arr[i][0]
-				} else if j+k > len(result[i])-1 {
-					pix = arr[i][len(result[i])-1]
-				} else {
-					pix = arr[i][j+k]
+	ParallelRows(height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range width {
+				var sum [4]float64
+				for k := -radius; k <= radius; k++ {
+					src := img.RGBA[4*img.Index(min(max(j+k, 0), width-1), i):]
+					weight := kernel[k+radius]
+					for c := range sum {
+						sum[c] += float64(src[c]) * weight
+					}
+				}
+				dst := tmp.RGBA[4*tmp.Index(j, i):]
+				for c := range sum {
+					dst[c] = uint8(sum[c])
 				}
-				weight := kernel[k+radius]
-				rc, rg, rb, ra := pix.R, pix.G, pix.B, pix.A
-				r += (float64(rc) * weight)
-				g += (float64(rg) * weight)
-				b += (float64(rb) * weight)
-				a += (float64(ra) * weight)
-			}
-			tmp[i][j] = Pixel{
-				R:         uint8(r),
-				G:         uint8(g),
-				B:         uint8(b),
-				A:         uint8(a),
-				Character: arr[i][j].Character,
 			}
 		}
-	}
+	})
 
 	// vertical pass
-	for i := range len(tmp) {
-		for j := range len(tmp[i]) {
-			var r, g, b, a float64 = 0, 0, 0, 0
-			for k := -radius; k <= radius; k++ {
-				var pix Pixel
-				if i+k < 0 {
-					pix = tmp[0][j]
-				} else if i+k > len(tmp)-1 {
-					pix = tmp[len(tmp)-1][j]
-				} else {
-					pix = tmp[i+k][j]
+	ParallelRows(height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range width {
+				var sum [4]float64
+				for k := -radius; k <= radius; k++ {
+					src := tmp.RGBA[4*tmp.Index(j, min(max(i+k, 0), height-1)):]
+					for c := range sum {
+						sum[c] += float64(src[c]) * kernel[k+radius]
+					}
+				}
+				dst := result.RGBA[4*result.Index(j, i):]
+				for c := range sum {
+					dst[c] = uint8(sum[c])
 				}
-				rc, rg, rb, ra := pix.R, pix.G, pix.B, pix.A
-				r += (float64(rc) * kernel[k+radius])
-				g += (float64(rg) * kernel[k+radius])
-				b += (float64(rb) * kernel[k+radius])
-				a += (float64(ra) * kernel[k+radius])
 			}
-			result[i][j].R = uint8(r)
-			result[i][j].G = uint8(g)
-			result[i][j].B = uint8(b)
-			result[i][j].A = uint8(a)
 		}
-	}
+	})
 
-	return result
+	result.UpdateLuminance()
+
+	return result, nil
 }
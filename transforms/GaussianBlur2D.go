@@ -9,17 +9,20 @@ import (
 )
 
 // Creates a Gaussian Blur effect with a kernel_size x kernel_size convolution matrix
-func GaussianBlur2D(img [][]Pixel, kernel_size int) [][]Pixel {
+func GaussianBlur2D(img [][]Pixel, kernel_size int) ([][]Pixel, error) {
 	if kernel_size%2 != 1 {
-		panic("Enter odd number for kernel_size")
+		return nil, fmt.Errorf("%w: %v, needs to be odd and positive", ErrInvalidKernel, kernel_size)
 	}
+
+	if len(img) == 0 || len(img[0]) == 0 {
+		return nil, ErrEmptyImage
+	}
+
 	kernel := createKernel2D(kernel_size)
 
 	blur1 := blur2D(img, kernel)
 
-	fmt.Printf("(%v, %v) \n", len(blur1), len(blur1[0]))
-
-	return blur1
+	return blur1, nil
 }
 
 func createKernel2D(matrix_size int) [][]float64 {
@@ -76,38 +79,40 @@ func blur2D(img [][]Pixel, kernel [][]float64) [][]Pixel {
 	kernel_size := len(kernel)
 	radius := kernel_size / 2
 
-	for i := range img_height {
-		min_y_val := -min(i, (radius))
-		max_y_val := min(img_height-1-i, (radius))
+	ParallelRows(img_height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			min_y_val := -min(i, (radius))
+			max_y_val := min(img_height-1-i, (radius))
 
-		for j := range img_width {
-			min_x_val := -min(j, radius)
-			max_x_val := min(img_width-1-j, radius)
+			for j := range img_width {
+				min_x_val := -min(j, radius)
+				max_x_val := min(img_width-1-j, radius)
 
-			var r, g, b, a float64 = 0, 0, 0, 0
+				var r, g, b, a float64 = 0, 0, 0, 0
 
-			for p := min_y_val; p <= max_y_val; p++ {
-				for q := min_x_val; q <= max_x_val; q++ {
-					pix := img[i+p][j+q]
+				for p := min_y_val; p <= max_y_val; p++ {
+					for q := min_x_val; q <= max_x_val; q++ {
+						pix := img[i+p][j+q]
 
-					rc, rg, rb, ra := pix.R, pix.G, pix.B, pix.A
+						rc, rg, rb, ra := pix.R, pix.G, pix.B, pix.A
 
-					r += (float64(rc) * kernel[p+radius][q+radius])
-					g += (float64(rg) * kernel[p+radius][q+radius])
-					b += (float64(rb) * kernel[p+radius][q+radius])
-					a += (float64(ra) * kernel[p+radius][q+radius])
+						r += (float64(rc) * kernel[p+radius][q+radius])
+						g += (float64(rg) * kernel[p+radius][q+radius])
+						b += (float64(rb) * kernel[p+radius][q+radius])
+						a += (float64(ra) * kernel[p+radius][q+radius])
+					}
 				}
-			}
 
-			blurred[i][j] = Pixel{
-				R:         uint8(r),
-				G:         uint8(g),
-				B:         uint8(b),
-				A:         uint8(a),
-				Character: img[i][j].Character,
+				blurred[i][j] = Pixel{
+					R:         uint8(r),
+					G:         uint8(g),
+					B:         uint8(b),
+					A:         uint8(a),
+					Character: img[i][j].Character,
+				}
 			}
 		}
-	}
+	})
 
 	return blurred
 }
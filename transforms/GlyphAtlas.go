@@ -0,0 +1,121 @@
+package transforms
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"sync"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/math/fixed"
+)
+
+/*
+A GlyphAtlas rasterizes every rune of a font at one size once, into an alpha mask, so drawing a character is a single draw.DrawMask
+instead of running the rasterizer again. Masks come out exactly like freetype.Context.DrawString's. Safe for concurrent use.
+*/
+type GlyphAtlas struct {
+	font    *truetype.Font
+	px_size float64
+
+	masks  sync.Map   // rune -> *image.Alpha, bounds relative to the glyph's baseline origin, empty for glyphs without ink
+	mu     sync.Mutex // guards raster
+	raster *freetype.Context
+}
+
+// Creates an atlas of font drawn px_size tall (72 DPI, like the freetype.Context OutputImage uses), rasterizing runes up front. Other runes are rasterized on first use.
+func NewGlyphAtlas(font *truetype.Font, px_size float64, runes []rune) *GlyphAtlas {
+	atlas := &GlyphAtlas{
+		font:    font,
+		px_size: px_size,
+	}
+
+	atlas.mu.Lock()
+	for _, r := range runes {
+		atlas.rasterize(r)
+	}
+	atlas.mu.Unlock()
+
+	return atlas
+}
+
+// How far from its baseline origin a glyph's mask can reach in any direction, in pixels. Glyphs are rasterized into a canvas this far out on every side.
+func (atlas *GlyphAtlas) Reach() int {
+	return int(math.Ceil(2 * atlas.px_size))
+}
+
+// alpha mask of r, rasterizing it if it's new
+func (atlas *GlyphAtlas) Mask(r rune) *image.Alpha {
+	if mask, ok := atlas.masks.Load(r); ok {
+		return mask.(*image.Alpha)
+	}
+
+	atlas.mu.Lock()
+	defer atlas.mu.Unlock()
+
+	return atlas.rasterize(r)
+}
+
+// call with mu held
+func (atlas *GlyphAtlas) rasterize(r rune) *image.Alpha {
+	if mask, ok := atlas.masks.Load(r); ok {
+		return mask.(*image.Alpha)
+	}
+
+	// room for glyphs that spill out of their cell (descenders, wide glyphs) on every side
+	pad := atlas.Reach()
+	canvas := image.NewAlpha(image.Rect(-pad, -pad, pad, pad))
+
+	if atlas.raster == nil {
+		atlas.raster = freetype.NewContext()
+		atlas.raster.SetDPI(72)
+		atlas.raster.SetFont(atlas.font)
+		atlas.raster.SetFontSize(atlas.px_size)
+		atlas.raster.SetSrc(image.Opaque)
+	}
+	atlas.raster.SetDst(canvas)
+	atlas.raster.SetClip(canvas.Bounds())
+
+	var mask *image.Alpha
+	if _, err := atlas.raster.DrawString(string(r), fixed.P(0, 0)); err == nil {
+		mask = trimAlpha(canvas)
+	} else {
+		mask = image.NewAlpha(image.Rectangle{})
+	}
+
+	atlas.masks.Store(r, mask)
+
+	return mask
+}
+
+// smallest sub image holding every non transparent pixel of img
+func trimAlpha(img *image.Alpha) *image.Alpha {
+	bounds := img.Bounds()
+	inked := image.Rectangle{}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if img.AlphaAt(x, y).A != 0 {
+				inked = inked.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+
+	return img.SubImage(inked).(*image.Alpha)
+}
+
+// Draws r over dst with its baseline origin at (x, y), clipped to clip. src is usually an *image.Uniform of the character's color.
+func (atlas *GlyphAtlas) DrawRune(dst draw.Image, clip image.Rectangle, x int, y int, r rune, src image.Image) {
+	mask := atlas.Mask(r)
+	bounds := mask.Bounds()
+	if bounds.Empty() {
+		return
+	}
+
+	dr := bounds.Add(image.Pt(x, y)).Intersect(clip)
+	if dr.Empty() {
+		return
+	}
+
+	draw.DrawMask(dst, dr, src, image.Point{}, mask, dr.Min.Sub(image.Pt(x, y)), draw.Over)
+}
@@ -0,0 +1,196 @@
+package transforms
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// *****************
+// GRADIENT OPERATORS
+// *****************
+
+/*
+A GradientOperator is the pair of kernels an edge pass convolves luminance with to get the gradient: Gx responds to brightness growing
+to the right, Gy to brightness growing upwards (the top row of a 3x3 Gy is the positive one). Kernels are square, odd sized ones
+centered on the pixel and even sized ones anchored (size-1)/2 from their top left. Both are scaled so a ramp gives the same response as
+with the 3x3 sobel kernels, so edge cutoffs mean the same whatever the operator.
+*/
+type GradientOperator struct {
+	Name   string
+	Gx, Gy [][]float64
+}
+
+/*
+Creates an operator from gx and gy (copied, then scaled as described on GradientOperator). Errors if they aren't square kernels of
+the same size or don't respond to a ramp along their axis.
+*/
+func NewGradientOperator(name string, gx [][]float64, gy [][]float64) (*GradientOperator, error) {
+	size := len(gx)
+	if size == 0 || len(gy) != size {
+		return nil, fmt.Errorf("%w: gradient kernels of %v and %v rows, need the same nonzero size", ErrInvalidKernel, len(gx), len(gy))
+	}
+	for k := range size {
+		if len(gx[k]) != size || len(gy[k]) != size {
+			return nil, fmt.Errorf("%w: gradient kernels need to be square", ErrInvalidKernel)
+		}
+	}
+
+	// responses to brightness going up by 1 per pixel rightwards and upwards, 8 with sobel
+	ramp_x, ramp_y := 0.0, 0.0
+	for k := range size {
+		for l := range size {
+			ramp_x += gx[k][l] * float64(l)
+			ramp_y -= gy[k][l] * float64(k)
+		}
+	}
+	if math.Abs(ramp_x) < 1e-9 || math.Abs(ramp_y) < 1e-9 {
+		return nil, fmt.Errorf("%w: gradient kernels don't respond to a ramp", ErrInvalidKernel)
+	}
+
+	op := &GradientOperator{Name: name, Gx: make([][]float64, size), Gy: make([][]float64, size)}
+	for k := range size {
+		op.Gx[k] = make([]float64, size)
+		op.Gy[k] = make([]float64, size)
+		for l := range size {
+			op.Gx[k][l] = gx[k][l] * 8 / ramp_x
+			op.Gy[k][l] = gy[k][l] * 8 / ramp_y
+		}
+	}
+
+	return op, nil
+}
+
+// x and y kernels of a separable operator: smooth across the derivative, derivative d left to right (or bottom to top for y)
+func separableOperator(name string, smooth []float64, d []float64) *GradientOperator {
+	size := len(smooth)
+	gx := make([][]float64, size)
+	gy := make([][]float64, size)
+	for k := range size {
+		gx[k] = make([]float64, size)
+		gy[k] = make([]float64, size)
+		for l := range size {
+			gx[k][l] = smooth[k] * d[l]
+			gy[k][l] = -d[k] * smooth[l]
+		}
+	}
+
+	op, _ := NewGradientOperator(name, gx, gy)
+
+	return op
+}
+
+// The 3x3 sobel kernels every edge pass has always used
+func SobelOperator() *GradientOperator {
+	return separableOperator("sobel", []float64{1, 2, 1}, []float64{-1, 0, 1})
+}
+
+// 5x5 sobel, smoother so less thrown off by noise, blurs fine detail
+func Sobel5Operator() *GradientOperator {
+	return separableOperator("sobel5", []float64{1, 4, 6, 4, 1}, []float64{-1, -2, 0, 2, 1})
+}
+
+// Scharr, 3x3 with the best rotational symmetry, so diagonal edges are misclassified less than with sobel
+func ScharrOperator() *GradientOperator {
+	return separableOperator("scharr", []float64{3, 10, 3}, []float64{-1, 0, 1})
+}
+
+// Prewitt, 3x3 without sobel's weighting of the center row
+func PrewittOperator() *GradientOperator {
+	return separableOperator("prewitt", []float64{1, 1, 1}, []float64{-1, 0, 1})
+}
+
+// Roberts cross, the two 2x2 diagonal differences combined into x and y. Sharpest and the most sensitive to noise.
+func RobertsOperator() *GradientOperator {
+	op, _ := NewGradientOperator("roberts",
+		[][]float64{
+			{-1, 1},
+			{-1, 1},
+		},
+		[][]float64{
+			{1, 1},
+			{-1, -1},
+		})
+
+	return op
+}
+
+var gradient_operators = []func() *GradientOperator{SobelOperator, Sobel5Operator, ScharrOperator, PrewittOperator, RobertsOperator}
+
+// Parses the name of a built-in operator: sobel, sobel5, scharr, prewitt or roberts
+func ParseGradientOperator(s string) (*GradientOperator, error) {
+	names := make([]string, len(gradient_operators))
+	for i, make_op := range gradient_operators {
+		op := make_op()
+		if strings.EqualFold(s, op.Name) {
+			return op, nil
+		}
+		names[i] = op.Name
+	}
+
+	return nil, fmt.Errorf("unknown gradient operator %q, use %s", s, strings.Join(names, ", "))
+}
+
+// Parses a kernel written row by row, rows separated by ';' and values by ',' (ex: "-1,0,1;-2,0,2;-1,0,1" is sobel's Gx)
+func ParseKernel(s string) ([][]float64, error) {
+	var kernel [][]float64
+	for _, row := range strings.Split(s, ";") {
+		values := strings.Split(row, ",")
+		parsed := make([]float64, len(values))
+		for l, value := range values {
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q isn't a number in kernel %q", ErrInvalidKernel, value, s)
+			}
+			parsed[l] = v
+		}
+		kernel = append(kernel, parsed)
+	}
+
+	return kernel, nil
+}
+
+// Size of the kernels and how far their anchor is from the top left
+func (op *GradientOperator) extent() (size int, radius int) {
+	return len(op.Gx), (len(op.Gx) - 1) / 2
+}
+
+/*
+Gradient of img's luminance with op at every pixel: magnitude sqrt(Gx^2 + Gy^2) and angle in [0, pi). No pixel is marked as an edge.
+Pixels the kernels don't fit around (the border) are left at 0.
+*/
+func Gradient(img *Plane, op *GradientOperator) *EdgeMap {
+	gradient := NewEdgeMap(img.Width, img.Height)
+	size, radius := op.extent()
+
+	ParallelRows(img.Height, func(start int, end int) {
+		for i := max(start, radius); i < min(end, img.Height-size+radius+1); i++ {
+			for j := radius; j < img.Width-size+radius+1; j++ {
+				x, y := op.apply(img, j, i)
+
+				index := gradient.Index(j, i)
+				gradient.Magnitude[index] = float32(math.Hypot(x, y))
+				gradient.Angle[index] = float32(math.Mod(math.Atan2(y, x)+math.Pi, math.Pi))
+			}
+		}
+	})
+
+	return gradient
+}
+
+// Gx and Gy responses at (j, i), which the kernels have to fit around
+func (op *GradientOperator) apply(img *Plane, j int, i int) (x float64, y float64) {
+	size, radius := op.extent()
+	for k := range size {
+		row := img.Lum[img.Index(j-radius, i-radius+k):]
+		gx, gy := op.Gx[k], op.Gy[k]
+		for l := range size {
+			lum := float64(row[l])
+			x += gx[l] * lum
+			y += gy[l] * lum
+		}
+	}
+
+	return x, y
+}
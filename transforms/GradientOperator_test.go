@@ -0,0 +1,39 @@
+package transforms
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseKernel(t *testing.T) {
+	gx, err := ParseKernel("-1,0,1; -2,0,2; -1,0,1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gy, err := ParseKernel("1,2,1;0,0,0;-1,-2,-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the written out sobel kernels make the built-in operator
+	op, err := NewGradientOperator("custom", gx, gy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sobel := SobelOperator(); !reflect.DeepEqual(op.Gx, sobel.Gx) || !reflect.DeepEqual(op.Gy, sobel.Gy) {
+		t.Errorf("parsed sobel kernels are %v %v, want %v %v", op.Gx, op.Gy, sobel.Gx, sobel.Gy)
+	}
+
+	for _, bad := range []string{"", "1,,2", "1;x", "1 2"} {
+		if _, err := ParseKernel(bad); !errors.Is(err, ErrInvalidKernel) {
+			t.Errorf("ParseKernel(%q): got %v, want ErrInvalidKernel", bad, err)
+		}
+	}
+
+	// parses, but isn't a usable pair
+	uneven, _ := ParseKernel("1,0,-1;2,0")
+	if _, err := NewGradientOperator("custom", uneven, uneven); !errors.Is(err, ErrInvalidKernel) {
+		t.Errorf("ragged kernel: got %v, want ErrInvalidKernel", err)
+	}
+}
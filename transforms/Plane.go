@@ -0,0 +1,106 @@
+package transforms
+
+/*
+A Plane is an image stored as flat structure-of-arrays planes instead of [][]Pixel: one contiguous slice per kind of data, pixel (x, y)
+at index y*Stride + x (times 4 in RGBA). Lum caches each pixel's Luminance so filters don't recompute it for every neighbour.
+*/
+type Plane struct {
+	Width, Height int
+	Stride        int       // pixels per row in the planes, usually Width
+	Lum           []float32 // luminance 0-255, see UpdateLuminance
+	RGBA          []uint8   // 4 bytes per pixel
+	Glyph         []rune    // character of every pixel, 0 for none
+}
+
+// Allocates a width x height plane of transparent black pixels without characters
+func NewPlane(width int, height int) *Plane {
+	size := width * height
+	return &Plane{
+		Width:  width,
+		Height: height,
+		Stride: width,
+		Lum:    make([]float32, size),
+		RGBA:   make([]uint8, 4*size),
+		Glyph:  make([]rune, size),
+	}
+}
+
+// Copies arr into a new plane, every row is as wide as the first
+func PlaneFromPixels(arr [][]Pixel) *Plane {
+	width := 0
+	if len(arr) > 0 {
+		width = len(arr[0])
+	}
+
+	plane := NewPlane(width, len(arr))
+	ParallelRows(len(arr), func(start int, end int) {
+		for y := start; y < end; y++ {
+			row := arr[y][:min(len(arr[y]), width)]
+			for x := range row {
+				plane.SetPixel(x, y, &row[x])
+			}
+		}
+	})
+
+	return plane
+}
+
+// Copies the plane back into a [][]Pixel
+func (plane *Plane) Pixels() [][]Pixel {
+	arr := make([][]Pixel, plane.Height)
+	ParallelRows(plane.Height, func(start int, end int) {
+		for y := start; y < end; y++ {
+			arr[y] = make([]Pixel, plane.Width)
+			for x := range arr[y] {
+				arr[y][x] = plane.Pixel(x, y)
+			}
+		}
+	})
+
+	return arr
+}
+
+func (plane *Plane) Index(x int, y int) int {
+	return y*plane.Stride + x
+}
+
+func (plane *Plane) Pixel(x int, y int) Pixel {
+	i := plane.Index(x, y)
+	c := plane.RGBA[4*i : 4*i+4]
+
+	return Pixel{R: c[0], G: c[1], B: c[2], A: c[3], Character: plane.Glyph[i]}
+}
+
+// Sets pixel (x, y) to p, luminance included
+func (plane *Plane) SetPixel(x int, y int, p *Pixel) {
+	i := plane.Index(x, y)
+	c := plane.RGBA[4*i : 4*i+4]
+	c[0], c[1], c[2], c[3] = p.R, p.G, p.B, p.A
+	plane.Glyph[i] = p.Character
+	plane.Lum[i] = float32(Luminance(p))
+}
+
+// Sets the pixel at index i to an opaque gray, luminance included
+func (plane *Plane) setGray(i int, v uint8) {
+	c := plane.RGBA[4*i : 4*i+4]
+	c[0], c[1], c[2], c[3] = v, v, v, 255
+	plane.Lum[i] = lum8(v, v, v)
+}
+
+// Recomputes Lum from RGBA, after writing RGBA directly
+func (plane *Plane) UpdateLuminance() {
+	ParallelRows(plane.Height, func(start int, end int) {
+		for y := start; y < end; y++ {
+			for x := range plane.Width {
+				i := plane.Index(x, y)
+				c := plane.RGBA[4*i : 4*i+4]
+				plane.Lum[i] = lum8(c[0], c[1], c[2])
+			}
+		}
+	})
+}
+
+// same as Luminance
+func lum8(r uint8, g uint8, b uint8) float32 {
+	return float32(0.2126*float64(r) + 0.7152*float64(g) + 0.0722*float64(b))
+}
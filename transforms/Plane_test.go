@@ -0,0 +1,73 @@
+package transforms
+
+import "testing"
+
+func TestPlaneRoundTrip(t *testing.T) {
+	arr := make([][]Pixel, 3)
+	for y := range arr {
+		arr[y] = make([]Pixel, 5)
+		for x := range arr[y] {
+			arr[y][x] = Pixel{R: uint8(10 * x), G: uint8(40 * y), B: uint8(x * y), A: uint8(200 + x), Character: rune('a' + 5*y + x)}
+		}
+	}
+
+	plane := PlaneFromPixels(arr)
+	if plane.Width != 5 || plane.Height != 3 || plane.Stride != 5 {
+		t.Fatalf("plane is %vx%v with stride %v, want 5x3 with stride 5", plane.Width, plane.Height, plane.Stride)
+	}
+
+	for y := range arr {
+		for x := range arr[y] {
+			i := plane.Index(x, y)
+			if i != y*5+x {
+				t.Errorf("Index(%v, %v) = %v, want %v", x, y, i, y*5+x)
+			}
+			if got := plane.Pixel(x, y); got != arr[y][x] {
+				t.Errorf("Pixel(%v, %v) = %+v, want %+v", x, y, got, arr[y][x])
+			}
+			if want := float32(Luminance(&arr[y][x])); plane.Lum[i] != want {
+				t.Errorf("Lum at (%v, %v) = %v, want %v", x, y, plane.Lum[i], want)
+			}
+		}
+	}
+
+	back := plane.Pixels()
+	if len(back) != len(arr) {
+		t.Fatalf("Pixels gave %v rows, want %v", len(back), len(arr))
+	}
+	for y := range arr {
+		if len(back[y]) != len(arr[y]) {
+			t.Fatalf("Pixels row %v has %v pixels, want %v", y, len(back[y]), len(arr[y]))
+		}
+		for x := range arr[y] {
+			if back[y][x] != arr[y][x] {
+				t.Errorf("round trip changed (%v, %v) from %+v to %+v", x, y, arr[y][x], back[y][x])
+			}
+		}
+	}
+}
+
+func TestPlaneFromPixelsRagged(t *testing.T) {
+	// rows are cut to the first row's width, short rows leave the rest transparent black
+	arr := [][]Pixel{
+		{{R: 1, A: 255}, {R: 2, A: 255}},
+		{{R: 3, A: 255}, {R: 4, A: 255}, {R: 5, A: 255}},
+		{{R: 6, A: 255}},
+	}
+
+	plane := PlaneFromPixels(arr)
+	if plane.Width != 2 || plane.Height != 3 {
+		t.Fatalf("plane is %vx%v, want 2x3", plane.Width, plane.Height)
+	}
+	if got := plane.Pixel(1, 1).R; got != 4 {
+		t.Errorf("Pixel(1, 1).R = %v, want 4", got)
+	}
+	if got := plane.Pixel(1, 2); got != (Pixel{}) {
+		t.Errorf("Pixel(1, 2) = %+v, want transparent black", got)
+	}
+
+	empty := PlaneFromPixels(nil)
+	if empty.Width != 0 || empty.Height != 0 || len(empty.Pixels()) != 0 {
+		t.Errorf("empty array made a %vx%v plane", empty.Width, empty.Height)
+	}
+}
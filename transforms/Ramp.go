@@ -0,0 +1,71 @@
+package transforms
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Ramp is a set of characters ordered from least to most dense. The darkest pixels get the first character, the brightest get the last.
+type Ramp []rune
+
+var named_ramps = map[string]string{
+	"standard":    " .:coCO0@■",
+	"blocks":      " ░▒▓█",
+	"detailed-70": " .'`^\",:;Il!i><~+_-?][}{1)(|\\/tfjrxnuvczXYUJCLQ0OZmwqpdbkhao*#MW&8%B@$",
+	"minimal":     " .:-=+*#%@",
+}
+
+// The ramp every filter used to hard-code: ' ' . : c o C O 0 @ ■
+func StandardRamp() Ramp {
+	return Ramp([]rune(named_ramps["standard"]))
+}
+
+// Returns a copy of the built-in ramp called name
+func NamedRamp(name string) (Ramp, bool) {
+	chars, ok := named_ramps[name]
+	if !ok {
+		return nil, false
+	}
+
+	return Ramp([]rune(chars)), true
+}
+
+// Names of the built-in ramps, sorted
+func RampNames() []string {
+	names := make([]string, 0, len(named_ramps))
+	for name := range named_ramps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Parses a ramp from the command line: either the name of a built-in ramp or the characters themselves, least dense first (ex: " .oO@").
+func ParseRamp(s string) (Ramp, error) {
+	if ramp, ok := NamedRamp(s); ok {
+		return ramp, nil
+	}
+
+	ramp := Ramp([]rune(s))
+	if len(ramp) < 2 {
+		return nil, fmt.Errorf("%w: %q needs at least 2 characters or one of %v", ErrInvalidRamp, s, RampNames())
+	}
+
+	return ramp, nil
+}
+
+// Picks the character for a luminance in the 0-1 range. An empty ramp behaves like StandardRamp.
+func (ramp Ramp) Rune(luminance float64) rune {
+	if len(ramp) == 0 {
+		ramp = StandardRamp()
+	}
+
+	bucket := int(luminance * float64(len(ramp)))
+
+	return ramp[max(0, min(bucket, len(ramp)-1))]
+}
+
+func (ramp Ramp) String() string {
+	return string(ramp)
+}
@@ -2,127 +2,112 @@ package transforms
 
 import (
 	"math"
-	"sync"
 )
 
 type CoordPair struct {
 	x, y int
 }
 
-func SobelFilterConc(arr [][]Pixel, result [][]Pixel, add_character bool, start CoordPair, end CoordPair, Gx [][]float64, Gy [][]float64) {
-	var rune_insert rune
-	if add_character {
-		rune_insert = ' '
-	} else {
-		rune_insert = rune(0)
-	}
-
-	// fmt.Printf("at (%v, %v) to (%v, %v)\n", start.x, start.y, end.x, end.y)
-	for i := start.y; i < end.y; i++ {
-		if i >= len(arr) {
-			break
-		}
-		for j := start.x; j < end.x; j++ {
-			if j >= len(arr[i]) {
-				break
-			}
-			if i == 0 || j == 0 || i == len(arr)-1 || j == len(arr[i])-1 {
-				result[i][j] = Pixel{
-					R:         0,
-					G:         0,
-					B:         0,
-					A:         255,
-					Character: rune_insert,
-				}
-				continue
-			}
-			x := float64(0.0)
-			y := float64(0.0)
-			for k := -1; k <= 1; k++ {
-				for l := -1; l <= 1; l++ {
-					x += Gx[k+1][l+1] * Luminance(&arr[i+k][j+l])
-					y += Gy[k+1][l+1] * Luminance(&arr[i+k][j+l])
-				}
-			}
+func SobelFilter(arr [][]Pixel, add_character bool) [][]Pixel {
+	return SobelPlane(PlaneFromPixels(arr), add_character).Pixels()
+}
 
-			// fmt.Printf("%v %v\n", x, y)
+const sobel_tile_size = 64
 
-			angle := math.Mod(math.Atan2(y, x)+math.Pi, math.Pi) // [0, pi]
-			r := rune(0)
-
-			switch {
-			case !add_character:
-				r = rune_insert
-			case angle < math.Pi/8 || angle >= 7*math.Pi/8:
-				r = '|'
-			case angle < 3*math.Pi/8:
-				r = '\\'
-			case angle < 5*math.Pi/8:
-				r = '-'
-			default:
-				r = '/'
-			}
+// Same as SobelFilter on a Plane, reading the cached luminance of every neighbour
+func SobelPlane(img *Plane, add_character bool) *Plane {
+	return gradientPlane(img, SobelOperator(), add_character, 100)
+}
 
-			end := uint8(min(255, math.Abs(x)+math.Abs(y)))
+/*
+SobelPlane with characters, edges being the pixels whose magnitude is above the cutoff threshold picks for this image's magnitudes (see
+Threshold). FixedThreshold(100) gives SobelPlane(img, true).
+*/
+func SobelPlaneThreshold(img *Plane, threshold Threshold) *Plane {
+	return GradientPlane(img, SobelOperator(), threshold)
+}
 
-			if add_character && !(end > 100) {
-				r = ' '
-			}
+// SobelPlaneThreshold with the gradient taken by op instead of the 3x3 sobel kernels
+func GradientPlane(img *Plane, op *GradientOperator, threshold Threshold) *Plane {
+	if threshold.Mode == ThresholdFixed {
+		return gradientPlane(img, op, true, threshold.Value)
+	}
 
-			result[i][j] = Pixel{
-				R:         end,
-				G:         end,
-				B:         end,
-				A:         255,
-				Character: r,
-			}
+	// every direction kept, then the ones at or below the cutoff are blanked
+	result := gradientPlane(img, op, true, -1)
 
-			// fmt.Printf("(%v %v %v %v) ", arr[i][j].R, arr[i][j].G, arr[i][j].B, arr[i][j].Character)
+	size, radius := op.extent()
+	var histogram [256]int
+	for i := radius; i < result.Height-size+radius+1; i++ {
+		for j := radius; j < result.Width-size+radius+1; j++ {
+			histogram[result.RGBA[4*result.Index(j, i)]]++
 		}
 	}
+	cutoff := threshold.Cutoff(&histogram)
+
+	ParallelRows(result.Height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range result.Width {
+				index := result.Index(j, i)
+				if float64(result.RGBA[4*index]) <= cutoff {
+					result.Glyph[index] = ' '
+				}
+			}
+		}
+	})
 
+	return result
 }
 
-func SobelFilter(arr [][]Pixel, add_character bool) [][]Pixel {
-	Gx := [][]float64{
-		{-1, 0, 1},
-		{-2, 0, 2},
-		{-1, 0, 1},
-	}
-	Gy := [][]float64{
-		{1, 2, 1},
-		{0, 0, 0},
-		{-1, -2, -1},
+// gradient pass where pixels with a magnitude at or below cutoff get no direction
+func gradientPlane(img *Plane, op *GradientOperator, add_character bool, cutoff float64) *Plane {
+	result := NewPlane(img.Width, img.Height)
+
+	if img.Width == 0 || img.Height == 0 {
+		return result
 	}
 
-	result := make([][]Pixel, len(arr))
+	// tiles cover every pixel, edges and last row included, for any image size
+	ParallelTiles(img.Width, img.Height, sobel_tile_size, func(x0 int, y0 int, x1 int, y1 int) {
+		sobelPlaneConc(img, result, add_character, cutoff, CoordPair{x0, y0}, CoordPair{x1, y1}, op)
+	})
 
-	for i := range len(arr) {
-		result[i] = make([]Pixel, len(arr[i]))
-	}
+	return result
+}
 
-	divisions := 10 // 8 threads ? ish
-	var group sync.WaitGroup
+func sobelPlaneConc(img *Plane, result *Plane, add_character bool, cutoff float64, start CoordPair, end CoordPair, op *GradientOperator) {
+	var rune_insert rune
+	if add_character {
+		rune_insert = ' '
+	} else {
+		rune_insert = rune(0)
+	}
 
-	incr := len(arr[0]) / divisions
+	size, radius := op.extent()
+	for i := start.y; i < min(end.y, img.Height); i++ {
+		for j := start.x; j < min(end.x, img.Width); j++ {
+			index := result.Index(j, i)
+			if i < radius || j < radius || i >= img.Height-size+radius+1 || j >= img.Width-size+radius+1 {
+				result.setGray(index, 0)
+				result.Glyph[index] = rune_insert
+				continue
+			}
+			x, y := op.apply(img, j, i)
 
-	// vertical partitions
-	for i := 0; i < len(arr[0]); i += incr {
-		start := CoordPair{
-			x: i,
-			y: 0,
-		}
-		end := CoordPair{
-			x: min(i + incr),
-			y: len(arr) - 1,
-		}
+			angle := math.Mod(math.Atan2(y, x)+math.Pi, math.Pi) // [0, pi]
+			r := rune_insert
+			if add_character {
+				r = EdgeGlyph(angle)
+			}
 
-		group.Go(func() {
-			(SobelFilterConc(arr, result, add_character, start, end, Gx, Gy))
-		})
-	}
+			edge := uint8(min(255, math.Abs(x)+math.Abs(y)))
 
-	group.Wait()
+			if add_character && !(float64(edge) > cutoff) {
+				r = ' '
+			}
 
-	return result
+			result.setGray(index, edge)
+			result.Glyph[index] = r
+		}
+	}
 }
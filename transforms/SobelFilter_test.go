@@ -0,0 +1,91 @@
+package transforms
+
+import (
+	"fmt"
+	"testing"
+)
+
+// black on the left of column step, white from it on
+func stepPlane(width int, height int, step int) *Plane {
+	plane := NewPlane(width, height)
+	for y := range height {
+		for x := range width {
+			p := Pixel{A: 255}
+			if x >= step {
+				p.R, p.G, p.B = 255, 255, 255
+			}
+			plane.SetPixel(x, y, &p)
+		}
+	}
+
+	return plane
+}
+
+func TestGradientPlaneSizes(t *testing.T) {
+	operators := []*GradientOperator{SobelOperator(), Sobel5Operator(), ScharrOperator(), PrewittOperator(), RobertsOperator()}
+	thresholds := []Threshold{FixedThreshold(100), OtsuThreshold(), TopPercent(10)}
+	sizes := [][2]int{{1, 9}, {9, 1}, {1, 1}, {2, 2}, {9, 9}, {70, 3}}
+
+	for _, op := range operators {
+		size, radius := op.extent()
+		for _, threshold := range thresholds {
+			for _, dims := range sizes {
+				name := fmt.Sprintf("%s %v %vx%v", op.Name, threshold, dims[0], dims[1])
+				img := stepPlane(dims[0], dims[1], dims[0]/2)
+
+				var result *Plane
+				func() {
+					defer func() {
+						if err := recover(); err != nil {
+							t.Fatalf("%s: panicked: %v", name, err)
+						}
+					}()
+					result = GradientPlane(img, op, threshold)
+				}()
+
+				if result.Width != dims[0] || result.Height != dims[1] {
+					t.Fatalf("%s: result is %vx%v", name, result.Width, result.Height)
+				}
+
+				// the border the kernel doesn't fit in is blank
+				for y := range result.Height {
+					for x := range result.Width {
+						inside := y >= radius && x >= radius && y < result.Height-size+radius+1 && x < result.Width-size+radius+1
+						index := result.Index(x, y)
+						if inside {
+							continue
+						}
+						if result.RGBA[4*index] != 0 || result.Glyph[index] != ' ' {
+							t.Errorf("%s: border pixel (%v, %v) is %v %q, want 0 ' '", name, x, y, result.RGBA[4*index], result.Glyph[index])
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestGradientPlaneStep(t *testing.T) {
+	img := stepPlane(9, 9, 4)
+	result := GradientPlane(img, SobelOperator(), FixedThreshold(100))
+
+	for y := 1; y < 8; y++ {
+		for x := 1; x < 8; x++ {
+			want := ' '
+			// the sobel kernel straddles the step from columns 3 and 4
+			if x == 3 || x == 4 {
+				want = '|'
+			}
+			if got := result.Glyph[result.Index(x, y)]; got != want {
+				t.Errorf("(%v, %v) is %q, want %q", x, y, got, want)
+			}
+		}
+	}
+
+	// the last row and column are border, not left out
+	for i := range 9 {
+		if result.Glyph[result.Index(8, i)] != ' ' || result.Glyph[result.Index(i, 8)] != ' ' {
+			t.Errorf("last row or column isn't blank at %v", i)
+		}
+	}
+}
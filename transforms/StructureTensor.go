@@ -0,0 +1,130 @@
+package transforms
+
+import (
+	"math"
+)
+
+// *****************
+// STRUCTURE TENSOR
+// *****************
+
+/*
+Edge orientation from the gaussian smoothed structure tensor: at every pixel the direction the gradient mostly points in over the
+neighbourhood, and how much the neighbourhood agrees on it. Pixel (x, y) is at index y*Width + x.
+*/
+type Orientation struct {
+	Width, Height int
+	Angle         []float32 // dominant gradient direction in [0, pi), like EdgeMap.Angle
+	Coherence     []float32 // 0 when gradients point every way (texture, flat areas) to 1 along a clean straight edge
+}
+
+/*
+Takes op's gradient of img, smooths the tensor [gx*gx gx*gy; gx*gy gy*gy] of every pixel with a kernel_size gaussian, and reads the
+orientation off its main eigenvector: angle = atan2(2*gxy, gxx - gyy) / 2. Coherence is (l1 - l2) / (l1 + l2) of the eigenvalues. A
+single raw atan2 per pixel flickers between neighbouring directions along a curve, the smoothed tensor doesn't.
+*/
+func StructureTensor(img *Plane, op *GradientOperator, kernel_size int) (*Orientation, error) {
+	kernel, err := gausKernel1D(kernel_size)
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := img.Width, img.Height
+	size := width * height
+	gxx, gxy, gyy := make([]float32, size), make([]float32, size), make([]float32, size)
+
+	op_size, radius := op.extent()
+	ParallelRows(height, func(start int, end int) {
+		for i := max(start, radius); i < min(end, height-op_size+radius+1); i++ {
+			for j := radius; j < width-op_size+radius+1; j++ {
+				x, y := op.apply(img, j, i)
+
+				index := i*width + j
+				gxx[index] = float32(x * x)
+				gxy[index] = float32(x * y)
+				gyy[index] = float32(y * y)
+			}
+		}
+	})
+
+	tensor := [3][]float32{gxx, gxy, gyy}
+	for _, component := range tensor {
+		smoothPlane(component, width, height, kernel)
+	}
+
+	orientation := &Orientation{
+		Width:     width,
+		Height:    height,
+		Angle:     make([]float32, size),
+		Coherence: make([]float32, size),
+	}
+
+	ParallelRows(height, func(start int, end int) {
+		for index := start * width; index < end*width; index++ {
+			xx, xy, yy := float64(gxx[index]), float64(gxy[index]), float64(gyy[index])
+
+			orientation.Angle[index] = float32(math.Mod(math.Atan2(2*xy, xx-yy)/2+math.Pi, math.Pi))
+			if trace := xx + yy; trace > 0 {
+				orientation.Coherence[index] = float32(min(1, math.Hypot(xx-yy, 2*xy)/trace))
+			}
+		}
+	})
+
+	return orientation, nil
+}
+
+// blurs a width x height plane of values in place with a separable kernel, clamping at the borders like blur
+func smoothPlane(values []float32, width int, height int, kernel []float64) {
+	radius := len(kernel) / 2
+	tmp := make([]float32, len(values))
+
+	ParallelRows(height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			row := values[i*width : (i+1)*width]
+			for j := range width {
+				sum := 0.0
+				for k := -radius; k <= radius; k++ {
+					sum += float64(row[min(max(j+k, 0), width-1)]) * kernel[k+radius]
+				}
+				tmp[i*width+j] = float32(sum)
+			}
+		}
+	})
+
+	ParallelRows(height, func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range width {
+				sum := 0.0
+				for k := -radius; k <= radius; k++ {
+					sum += float64(tmp[min(max(i+k, 0), height-1)*width+j]) * kernel[k+radius]
+				}
+				values[i*width+j] = float32(sum)
+			}
+		}
+	})
+}
+
+/*
+Redraws the edge pixels of edges (characters other than ' ' and 0, like SobelPlane writes) in the direction of orientation, so the
+glyph holds steady along a contour. Edge pixels less coherent than min_coherence (0-1) are texture rather than a contour and lose their
+character.
+*/
+func OrientEdges(edges *Plane, orientation *Orientation, min_coherence float64) {
+	ParallelRows(min(edges.Height, orientation.Height), func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range min(edges.Width, orientation.Width) {
+				index := edges.Index(j, i)
+				if edges.Glyph[index] == ' ' || edges.Glyph[index] == 0 {
+					continue
+				}
+
+				oriented := i*orientation.Width + j
+				if float64(orientation.Coherence[oriented]) < min_coherence {
+					edges.Glyph[index] = ' '
+				} else {
+					edges.Glyph[index] = EdgeGlyph(float64(orientation.Angle[oriented]))
+				}
+			}
+		}
+	})
+}
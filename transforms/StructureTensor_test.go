@@ -0,0 +1,117 @@
+package transforms
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// white where x+y >= sum, black elsewhere
+func diagonalPlane(width int, height int, sum int) *Plane {
+	plane := NewPlane(width, height)
+	for y := range height {
+		for x := range width {
+			p := Pixel{A: 255}
+			if x+y >= sum {
+				p.R, p.G, p.B = 255, 255, 255
+			}
+			plane.SetPixel(x, y, &p)
+		}
+	}
+
+	return plane
+}
+
+func noisePlane(width int, height int, seed int64) *Plane {
+	random := rand.New(rand.NewSource(seed))
+	plane := NewPlane(width, height)
+	for y := range height {
+		for x := range width {
+			v := uint8(random.Intn(256))
+			plane.SetPixel(x, y, &Pixel{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	return plane
+}
+
+func TestStructureTensorStraightEdge(t *testing.T) {
+	tests := []struct {
+		name  string
+		img   *Plane
+		edge  func(i int) (x int, y int) // i-th pixel along the edge
+		angle float64
+	}{
+		{"vertical", stepPlane(32, 32, 16), func(i int) (int, int) { return 16, i }, 0},
+		{"diagonal", diagonalPlane(32, 32, 32), func(i int) (int, int) { return 32 - i, i }, 3 * math.Pi / 4},
+	}
+
+	for _, test := range tests {
+		orientation, err := StructureTensor(test.img, SobelOperator(), 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// away from the borders, where the gradient isn't taken
+		for i := 8; i < 24; i++ {
+			x, y := test.edge(i)
+			index := y*orientation.Width + x
+
+			if coherence := orientation.Coherence[index]; coherence < 0.99 {
+				t.Errorf("%s: coherence %.3f at (%v, %v), want near 1", test.name, coherence, x, y)
+			}
+
+			// angles wrap at pi
+			diff := math.Abs(float64(orientation.Angle[index]) - test.angle)
+			if diff = min(diff, math.Pi-diff); diff > 0.01 {
+				t.Errorf("%s: angle %.3f at (%v, %v), want %.3f", test.name, orientation.Angle[index], x, y, test.angle)
+			}
+		}
+	}
+}
+
+func TestOrientEdgesCoherence(t *testing.T) {
+	const min_coherence, tensor_blur = 0.5, 15
+
+	count := func(edges *Plane) int {
+		n := 0
+		for _, r := range edges.Glyph {
+			if r != ' ' && r != 0 {
+				n++
+			}
+		}
+		return n
+	}
+
+	// every step edge pixel is coherent, keeps its character and is turned to '|'
+	step := stepPlane(32, 32, 16)
+	edges := SobelPlane(step, true)
+	before := count(edges)
+	orientation, err := StructureTensor(step, SobelOperator(), tensor_blur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	OrientEdges(edges, orientation, min_coherence)
+	if after := count(edges); after != before || before == 0 {
+		t.Errorf("step edge went from %v to %v edge pixels, want all of them kept", before, after)
+	}
+	for index, r := range edges.Glyph {
+		if r != ' ' && r != '|' {
+			t.Errorf("step edge pixel %v oriented to %q, want '|'", index, r)
+			break
+		}
+	}
+
+	// noise points every way, nearly all of it is dropped
+	noise := noisePlane(64, 64, 1)
+	edges = SobelPlane(noise, true)
+	before = count(edges)
+	orientation, err = StructureTensor(noise, SobelOperator(), tensor_blur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	OrientEdges(edges, orientation, min_coherence)
+	if after := count(edges); before == 0 || after > before/10 {
+		t.Errorf("noise went from %v to %v edge pixels, want at most a tenth kept", before, after)
+	}
+}
@@ -0,0 +1,144 @@
+package transforms
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// *****************
+// EDGE THRESHOLDS
+// *****************
+
+// How the sobel magnitude cutoff between edges and non edges is picked
+type ThresholdMode int
+
+const (
+	ThresholdFixed   ThresholdMode = iota // the same cutoff for every image
+	ThresholdOtsu                         // Otsu's method on the image's magnitude histogram
+	ThresholdPercent                      // the strongest share of pixels are edges
+)
+
+/*
+Picks the sobel magnitude (0-255) a pixel has to be above to count as an edge. Value is the cutoff for ThresholdFixed and the share of
+pixels kept (0-1) for ThresholdPercent, Otsu ignores it. Adaptive cutoffs find edges in dark low contrast photos and keep busy ones from
+drowning in them, where a fixed one only suits some images.
+*/
+type Threshold struct {
+	Mode  ThresholdMode
+	Value float64
+}
+
+// Pixels with a magnitude above cutoff are edges, FixedThreshold(100) is what SobelPlane has always used
+func FixedThreshold(cutoff float64) Threshold {
+	return Threshold{Mode: ThresholdFixed, Value: cutoff}
+}
+
+// Cutoff picked per image by Otsu's method, splitting the magnitudes into the two classes with the least variance within each
+func OtsuThreshold() Threshold {
+	return Threshold{Mode: ThresholdOtsu}
+}
+
+// The strongest percent (0-100) of pixels are edges
+func TopPercent(percent float64) Threshold {
+	return Threshold{Mode: ThresholdPercent, Value: percent / 100}
+}
+
+// Parses a fixed cutoff ("100"), "otsu" or a top percentage ("10%")
+func ParseThreshold(s string) (Threshold, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "otsu") {
+		return OtsuThreshold(), nil
+	}
+
+	if percent, ok := strings.CutSuffix(s, "%"); ok {
+		v, err := strconv.ParseFloat(percent, 64)
+		if err != nil || v < 0 || v > 100 {
+			return Threshold{}, fmt.Errorf("invalid threshold %q, percentages go from 0%% to 100%%", s)
+		}
+		return TopPercent(v), nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || v > 255 {
+		return Threshold{}, fmt.Errorf("invalid threshold %q, use a magnitude 0-255, \"otsu\" or a percentage like \"10%%\"", s)
+	}
+
+	return FixedThreshold(v), nil
+}
+
+func (threshold Threshold) String() string {
+	switch threshold.Mode {
+	case ThresholdOtsu:
+		return "otsu"
+	case ThresholdPercent:
+		return strconv.FormatFloat(threshold.Value*100, 'g', -1, 64) + "%"
+	}
+
+	return strconv.FormatFloat(threshold.Value, 'g', -1, 64)
+}
+
+// The cutoff for a magnitude histogram (count of pixels at every magnitude 0-255)
+func (threshold Threshold) Cutoff(histogram *[256]int) float64 {
+	switch threshold.Mode {
+	case ThresholdOtsu:
+		return otsu(histogram)
+	case ThresholdPercent:
+		return topShare(histogram, threshold.Value)
+	}
+
+	return threshold.Value
+}
+
+// magnitude maximizing the variance between the pixels at or below it and the ones above. With every pixel at one magnitude there is nothing to split, that magnitude is the cutoff so none are edges.
+func otsu(histogram *[256]int) float64 {
+	total, sum, highest := 0, 0.0, 0
+	for v, count := range histogram {
+		total += count
+		sum += float64(v * count)
+		if count > 0 {
+			highest = v
+		}
+	}
+
+	best, best_variance := highest, -1.0
+	below, below_sum := 0, 0.0
+	for v, count := range histogram {
+		below += count
+		below_sum += float64(v * count)
+		above := total - below
+		if below == 0 {
+			continue
+		}
+		if above == 0 {
+			break
+		}
+
+		mean_below := below_sum / float64(below)
+		mean_above := (sum - below_sum) / float64(above)
+		variance := float64(below) * float64(above) * (mean_below - mean_above) * (mean_below - mean_above)
+		if variance > best_variance {
+			best, best_variance = v, variance
+		}
+	}
+
+	return float64(best)
+}
+
+// smallest magnitude with no more than share (0-1) of the pixels above it
+func topShare(histogram *[256]int, share float64) float64 {
+	total := 0
+	for _, count := range histogram {
+		total += count
+	}
+
+	kept := 0
+	for v := 255; v > 0; v-- {
+		kept += histogram[v]
+		if float64(kept) > share*float64(total) {
+			return float64(v)
+		}
+	}
+
+	return 0
+}
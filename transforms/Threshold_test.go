@@ -0,0 +1,157 @@
+package transforms
+
+import (
+	"math"
+	"testing"
+)
+
+// histogram with count pixels at each magnitude of counts
+func histogramOf(counts map[int]int) *[256]int {
+	var histogram [256]int
+	for v, count := range counts {
+		histogram[v] = count
+	}
+
+	return &histogram
+}
+
+// gaussian bump of total pixels around mean
+func addBump(histogram *[256]int, mean float64, sigma float64, total int) {
+	for v := range histogram {
+		histogram[v] += int(math.Round(float64(total) * math.Exp(-(float64(v)-mean)*(float64(v)-mean)/(2*sigma*sigma)) / (sigma * math.Sqrt(2*math.Pi))))
+	}
+}
+
+// pixels of histogram above cutoff
+func above(histogram *[256]int, cutoff float64) int {
+	count := 0
+	for v, n := range histogram {
+		if float64(v) > cutoff {
+			count += n
+		}
+	}
+
+	return count
+}
+
+func TestOtsu(t *testing.T) {
+	var bumps [256]int
+	addBump(&bumps, 30, 8, 9000)
+	addBump(&bumps, 180, 12, 1000)
+
+	tests := []struct {
+		name     string
+		hist     *[256]int
+		min, max float64 // cutoff range
+	}{
+		{"two spikes", histogramOf(map[int]int{10: 900, 200: 100}), 10, 199},
+		{"two bumps", &bumps, 55, 145},
+		{"even spikes", histogramOf(map[int]int{0: 500, 255: 500}), 0, 254},
+		{"all zero", histogramOf(map[int]int{0: 1000}), 0, 0},
+		{"empty", histogramOf(nil), 0, 0},
+		{"single non zero pixel", histogramOf(map[int]int{0: 999, 200: 1}), 0, 199},
+		{"one magnitude", histogramOf(map[int]int{120: 1000}), 120, 120},
+	}
+
+	for _, test := range tests {
+		if cutoff := OtsuThreshold().Cutoff(test.hist); cutoff < test.min || cutoff > test.max {
+			t.Errorf("%s: cutoff %v, want %v-%v", test.name, cutoff, test.min, test.max)
+		}
+	}
+}
+
+func TestTopPercent(t *testing.T) {
+	spikes := histogramOf(map[int]int{10: 900, 200: 100})
+	spread := histogramOf(nil)
+	for v := range 100 {
+		spread[v] = 10 // 1000 pixels, 1% at each magnitude 0-99
+	}
+
+	tests := []struct {
+		name    string
+		hist    *[256]int
+		percent float64
+		edges   int // pixels above the cutoff
+	}{
+		{"top spike", spikes, 10, 100},
+		{"less than the top spike", spikes, 5, 0},
+		{"more than the top spike", spikes, 50, 100},
+		{"spread 25%", spread, 25, 250},
+		{"spread 33%", spread, 33, 330},
+		{"0%", spikes, 0, 0},
+		{"100%", spikes, 100, 1000},
+		{"100% never counts 0", histogramOf(map[int]int{0: 10, 50: 10}), 100, 10},
+		{"all zero", histogramOf(map[int]int{0: 1000}), 10, 0},
+		{"all zero at 100%", histogramOf(map[int]int{0: 1000}), 100, 0},
+		{"empty", histogramOf(nil), 10, 0},
+		{"single non zero pixel", histogramOf(map[int]int{0: 999, 200: 1}), 1, 1},
+		{"single non zero pixel at 0%", histogramOf(map[int]int{0: 999, 200: 1}), 0, 0},
+	}
+
+	for _, test := range tests {
+		cutoff := TopPercent(test.percent).Cutoff(test.hist)
+		if got := above(test.hist, cutoff); got != test.edges {
+			t.Errorf("%s: cutoff %v keeps %v pixels, want %v", test.name, cutoff, got, test.edges)
+		}
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Threshold
+		ok   bool
+	}{
+		{"100", FixedThreshold(100), true},
+		{" otsu ", OtsuThreshold(), true},
+		{"OTSU", OtsuThreshold(), true},
+		{"10%", TopPercent(10), true},
+		{"0%", TopPercent(0), true},
+		{"100%", TopPercent(100), true},
+		{"101%", Threshold{}, false},
+		{"-1", Threshold{}, false},
+		{"256", Threshold{}, false},
+		{"edges", Threshold{}, false},
+	}
+
+	for _, test := range tests {
+		got, err := ParseThreshold(test.in)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("ParseThreshold(%q) = %v, %v, want %v (ok %v)", test.in, got, err, test.want, test.ok)
+		}
+		if test.ok {
+			if again, err := ParseThreshold(got.String()); err != nil || again != got {
+				t.Errorf("%v doesn't round trip through String: %v, %v", got, again, err)
+			}
+		}
+	}
+}
+
+func TestGradientPlaneFlat(t *testing.T) {
+	flat := stepPlane(12, 12, 0)
+	for _, threshold := range []Threshold{OtsuThreshold(), TopPercent(0), TopPercent(100), FixedThreshold(0)} {
+		result := GradientPlane(flat, SobelOperator(), threshold)
+		for index, r := range result.Glyph {
+			if r != ' ' {
+				t.Errorf("%v: flat plane has an edge %q at %v", threshold, r, index)
+				break
+			}
+		}
+	}
+
+	// a single bright pixel only makes edges of the pixels around it
+	dot := stepPlane(12, 12, 12)
+	dot.SetPixel(6, 6, &Pixel{R: 255, G: 255, B: 255, A: 255})
+	for _, threshold := range []Threshold{OtsuThreshold(), TopPercent(10), TopPercent(100)} {
+		result := GradientPlane(dot, SobelOperator(), threshold)
+		edges := 0
+		for _, r := range result.Glyph {
+			if r != ' ' {
+				edges++
+			}
+		}
+		if edges == 0 || edges > 8 {
+			t.Errorf("%v: %v edge pixels around a single bright pixel, want 1-8", threshold, edges)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package transforms
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// *****************
+// WORKER SCHEDULING
+// *****************
+
+// goroutines every stage splits its work across, 0 means runtime.GOMAXPROCS(0)
+var worker_count atomic.Int64
+
+// Sets how many goroutines the transforms (and sampling) split their work across. n <= 0 goes back to runtime.GOMAXPROCS(0).
+func SetWorkers(n int) {
+	worker_count.Store(int64(max(0, n)))
+}
+
+// How many goroutines work is split across, see SetWorkers
+func Workers() int {
+	if n := worker_count.Load(); n > 0 {
+		return int(n)
+	}
+
+	return runtime.GOMAXPROCS(0)
+}
+
+// bands handed out per worker, more than one so a slow band doesn't hold the others up
+const bands_per_worker = 4
+
+/*
+Runs band over rows [0, n) split into row bands, spread across Workers() goroutines. Bands never overlap and every row is covered once,
+whatever n is. Returns once every band is done.
+*/
+func ParallelRows(n int, band func(start int, end int)) {
+	if n <= 0 {
+		return
+	}
+
+	workers := min(n, Workers())
+	if workers <= 1 {
+		band(0, n)
+		return
+	}
+
+	size := max(1, n/(workers*bands_per_worker))
+	var next atomic.Int64
+	var group sync.WaitGroup
+	for range workers {
+		group.Go(func() {
+			for {
+				start := int(next.Add(int64(size))) - size
+				if start >= n {
+					return
+				}
+				band(start, min(start+size, n))
+			}
+		})
+	}
+
+	group.Wait()
+}
+
+/*
+Runs tile over a width x height area split into tile_size x tile_size tiles (smaller at the right and bottom edges), spread across
+Workers() goroutines like ParallelRows.
+*/
+func ParallelTiles(width int, height int, tile_size int, tile func(x0 int, y0 int, x1 int, y1 int)) {
+	if width <= 0 || height <= 0 {
+		return
+	}
+	tile_size = max(1, tile_size)
+
+	columns := (width + tile_size - 1) / tile_size
+	rows := (height + tile_size - 1) / tile_size
+
+	ParallelRows(columns*rows, func(start int, end int) {
+		for t := start; t < end; t++ {
+			x0, y0 := (t%columns)*tile_size, (t/columns)*tile_size
+			tile(x0, y0, min(x0+tile_size, width), min(y0+tile_size, height))
+		}
+	})
+}
@@ -0,0 +1,18 @@
+package transforms
+
+import "errors"
+
+// *****************
+// ERRORS
+// *****************
+
+var (
+	// returned (wrapped) when a blur is asked for a kernel that is even or smaller than 1
+	ErrInvalidKernel = errors.New("transforms: invalid kernel size")
+	// returned (wrapped) when an AsciiImageBuffer runs out of room for the runes written to it
+	ErrBufferOverflow = errors.New("transforms: image buffer overflow")
+	// returned (wrapped) when a transform is given an array with no pixels
+	ErrEmptyImage = errors.New("transforms: empty image")
+	// returned (wrapped) when a character ramp can't be parsed
+	ErrInvalidRamp = errors.New("transforms: invalid ramp")
+)
@@ -13,22 +13,26 @@ func Luminance(p *Pixel) float64 {
 	return float64(0.2126*float64(red) + 0.7152*(float64(green)) + 0.0722*float64(blue)) // Luminance from 0-255
 }
 
-func luminize(p *Pixel, mapping map[int]rune) rune {
+func luminize(p *Pixel, ramp Ramp) rune {
 	luminance := Luminance(p) / 255
 
-	lumBuckets := min(int(luminance*10), 9) // push into buckets of 0-9 (mapping)
-
-	return mapping[lumBuckets]
+	return ramp.Rune(luminance) // push into len(ramp) buckets
 }
 
-func LuminFilter(arr [][]Pixel, mapping map[int]rune) {
-	for i := range len(arr) {
-		for j := range len(arr[i]) {
-			cur := &arr[i][j]
-			run := luminize(cur, mapping)
-			cur.Character = run
-		}
+func LuminFilter(arr [][]Pixel, ramp Ramp) {
+	if len(ramp) == 0 {
+		ramp = StandardRamp()
 	}
+
+	ParallelRows(len(arr), func(start int, end int) {
+		for i := start; i < end; i++ {
+			for j := range len(arr[i]) {
+				cur := &arr[i][j]
+				run := luminize(cur, ramp)
+				cur.Character = run
+			}
+		}
+	})
 }
 
 func Normalize(p *Pixel) color.RGBA {